@@ -0,0 +1,266 @@
+package tracerr
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultAggregatorLimit is a number of groups used if limit is not set.
+const defaultAggregatorLimit = 100
+
+// maxSamples is a maximum number of distinct messages stored in a group.
+const maxSamples = 5
+
+// timeFormat is a format of time in aggregator reports.
+const timeFormat = "2006-01-02 15:04:05"
+
+// Group contains errors with the same fingerprint.
+type Group struct {
+	// Fingerprint of errors, see Fingerprint.
+	Fingerprint string
+	// Count is a number of errors in group.
+	Count int
+	// FirstSeen is a time when the first error was added.
+	FirstSeen time.Time
+	// LastSeen is a time when the last error was added.
+	LastSeen time.Time
+	// Messages contains distinct messages of errors,
+	// up to 5 first ones.
+	Messages []string
+	// Err is the first error added to group.
+	Err error
+}
+
+// MarshalJSON returns group encoded to JSON,
+// error is encoded the same way as in MarshalJSON.
+func (g Group) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Fingerprint string     `json:"fingerprint"`
+		Code        string     `json:"code"`
+		Count       int        `json:"count"`
+		FirstSeen   time.Time  `json:"first_seen"`
+		LastSeen    time.Time  `json:"last_seen"`
+		Messages    []string   `json:"messages"`
+		Error       *jsonError `json:"error"`
+	}{
+		Fingerprint: g.Fingerprint,
+		Code:        fingerprintCode(g.Fingerprint),
+		Count:       g.Count,
+		FirstSeen:   g.FirstSeen,
+		LastSeen:    g.LastSeen,
+		Messages:    g.Messages,
+		Error:       toJSON(g.Err, nil),
+	})
+}
+
+// Aggregator groups errors by fingerprint and counts them,
+// so a summary could be printed instead of each error.
+//
+// Only a limited number of groups is stored,
+// when the limit is reached, the least frequent group is evicted.
+// Errors without stack trace are grouped together.
+//
+// It's safe for concurrent use.
+type Aggregator struct {
+	mutex  sync.Mutex
+	limit  int
+	groups map[string]*Group
+	total  int
+	// evicted is a number of errors in evicted groups.
+	evicted int
+}
+
+// NewAggregator creates an aggregator that stores up to limit groups.
+// If limit is not positive, 100 groups are stored.
+func NewAggregator(limit int) *Aggregator {
+	if limit <= 0 {
+		limit = defaultAggregatorLimit
+	}
+	return &Aggregator{
+		limit:  limit,
+		groups: map[string]*Group{},
+	}
+}
+
+// Add adds error to its group. Nil errors are ignored.
+func (a *Aggregator) Add(err error) {
+	if err == nil {
+		return
+	}
+	fingerprint := Fingerprint(err)
+	message := err.Error()
+	now := time.Now()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.total++
+	g, ok := a.groups[fingerprint]
+	if !ok {
+		if len(a.groups) >= a.limit {
+			a.evict()
+		}
+		g = &Group{
+			Fingerprint: fingerprint,
+			FirstSeen:   now,
+			Err:         err,
+		}
+		a.groups[fingerprint] = g
+	}
+	g.Count++
+	g.LastSeen = now
+	if len(g.Messages) < maxSamples && !contains(g.Messages, message) {
+		g.Messages = append(g.Messages, message)
+	}
+}
+
+// evict removes the least frequent group, the least recent one of them.
+func (a *Aggregator) evict() {
+	var victim *Group
+	for _, g := range a.groups {
+		if victim == nil || g.Count < victim.Count ||
+			g.Count == victim.Count && g.LastSeen.Before(victim.LastSeen) {
+			victim = g
+		}
+	}
+	if victim != nil {
+		a.evicted += victim.Count
+		delete(a.groups, victim.Fingerprint)
+	}
+}
+
+// Top returns up to n most frequent groups, or all of them if n is not positive.
+// Groups with the same count are ordered by the time they were last seen,
+// the most recent first.
+func (a *Aggregator) Top(n int) []Group {
+	a.mutex.Lock()
+	groups := make([]Group, 0, len(a.groups))
+	for _, g := range a.groups {
+		group := *g
+		group.Messages = append([]string(nil), g.Messages...)
+		groups = append(groups, group)
+	}
+	a.mutex.Unlock()
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].LastSeen.After(groups[j].LastSeen)
+	})
+	if n > 0 && n < len(groups) {
+		groups = groups[:n]
+	}
+	return groups
+}
+
+// Total returns a number of added errors, including ones in evicted groups.
+func (a *Aggregator) Total() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.total
+}
+
+// Reset removes all groups.
+func (a *Aggregator) Reset() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.groups = map[string]*Group{}
+	a.total = 0
+	a.evicted = 0
+}
+
+// Sprint returns a report of up to n most frequent groups,
+// where each group is printed the same way as in Sprint.
+// All groups are reported if n is not positive.
+func (a *Aggregator) Sprint(n int) string {
+	return a.sprint(n, []int{0}, false)
+}
+
+// SprintSource returns a report of up to n most frequent groups,
+// where each group is printed the same way as in SprintSource.
+func (a *Aggregator) SprintSource(n int, nums ...int) string {
+	return a.sprint(n, nums, false)
+}
+
+// SprintSourceColor returns a report of up to n most frequent groups,
+// where each group is printed the same way as in SprintSourceColor.
+func (a *Aggregator) SprintSourceColor(n int, nums ...int) string {
+	return a.sprint(n, nums, true)
+}
+
+func (a *Aggregator) sprint(n int, nums []int, colorized bool) string {
+	groups := a.Top(n)
+	a.mutex.Lock()
+	total, evicted, count := a.total, a.evicted, len(a.groups)
+	a.mutex.Unlock()
+
+	summary := fmt.Sprintf("%d %s in %d %s", total, plural(total, "error", "errors"), count, plural(count, "group", "groups"))
+	if evicted > 0 {
+		summary += fmt.Sprintf(", %d in evicted groups", evicted)
+	}
+	if colorized {
+		summary = bold(summary)
+	}
+	rows := []string{summary}
+	for i, g := range groups {
+		header := fmt.Sprintf("#%d", i+1)
+		if code := fingerprintCode(g.Fingerprint); code != "" {
+			header += " [" + code + "]"
+		}
+		header += fmt.Sprintf(
+			" %d %s, first seen %s, last seen %s",
+			g.Count, plural(g.Count, "time", "times"),
+			g.FirstSeen.Format(timeFormat), g.LastSeen.Format(timeFormat),
+		)
+		if colorized {
+			header = bold(header)
+		}
+		rows = append(rows, "", header)
+		for _, message := range g.Messages[1:] {
+			note := "also: " + message
+			if colorized {
+				note = black(note)
+			}
+			rows = append(rows, note)
+		}
+		rows = append(rows, strings.TrimRight(sprint(g.Err, nums, colorized), "\n"))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// MarshalJSON returns JSON report with all groups,
+// the most frequent first.
+func (a *Aggregator) MarshalJSON() ([]byte, error) {
+	groups := a.Top(0)
+	a.mutex.Lock()
+	total, evicted := a.total, a.evicted
+	a.mutex.Unlock()
+	return json.Marshal(struct {
+		Total   int     `json:"total"`
+		Evicted int     `json:"evicted,omitempty"`
+		Groups  []Group `json:"groups"`
+	}{
+		Total:   total,
+		Evicted: evicted,
+		Groups:  groups,
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,119 @@
+package tracerr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestAggregator(t *testing.T) {
+	a := tracerr.NewAggregator(0)
+	for i := 0; i < 3; i++ {
+		a.Add(tracerr.Errorf("retry %d", i%2))
+	}
+	a.Add(tracerr.New("other error"))
+	a.Add(nil)
+
+	if a.Total() != 4 {
+		t.Errorf("a.Total() = %#v; want %#v", a.Total(), 4)
+	}
+	groups := a.Top(0)
+	if len(groups) != 2 {
+		t.Fatalf("len(a.Top(0)) = %#v; want %#v", len(groups), 2)
+	}
+	first := groups[0]
+	if first.Count != 3 {
+		t.Errorf("a.Top(0)[0].Count = %#v; want %#v", first.Count, 3)
+	}
+	if fmt.Sprint(first.Messages) != "[retry 0 retry 1]" {
+		t.Errorf("a.Top(0)[0].Messages = %#v; want %#v", first.Messages, []string{"retry 0", "retry 1"})
+	}
+	if first.Err.Error() != "retry 0" {
+		t.Errorf("a.Top(0)[0].Err.Error() = %#v; want %#v", first.Err.Error(), "retry 0")
+	}
+	if first.Fingerprint != tracerr.Fingerprint(first.Err) {
+		t.Errorf("a.Top(0)[0].Fingerprint = %#v; want %#v", first.Fingerprint, tracerr.Fingerprint(first.Err))
+	}
+	if first.LastSeen.Before(first.FirstSeen) {
+		t.Errorf("a.Top(0)[0].LastSeen = %v; want after %v", first.LastSeen, first.FirstSeen)
+	}
+	if len(a.Top(1)) != 1 {
+		t.Errorf("len(a.Top(1)) = %#v; want %#v", len(a.Top(1)), 1)
+	}
+
+	output := a.Sprint(1)
+	rows := strings.Split(output, "\n")
+	if rows[0] != "4 errors in 2 groups" {
+		t.Errorf("a.Sprint(1) summary = %#v; want %#v", rows[0], "4 errors in 2 groups")
+	}
+	header := "#1 [" + tracerr.FingerprintCode(first.Err) + "] 3 times, first seen "
+	if !strings.HasPrefix(rows[2], header) {
+		t.Errorf("a.Sprint(1) header = %#v; want prefix %#v", rows[2], header)
+	}
+	if rows[3] != "also: retry 1" || rows[4] != "retry 0" {
+		t.Errorf("a.Sprint(1) = %#v", output)
+	}
+	if strings.Contains(output, "other error") {
+		t.Errorf("a.Sprint(1) = %#v; want only the top group", output)
+	}
+	if !strings.Contains(a.SprintSource(0), "other error") {
+		t.Errorf("a.SprintSource(0) = %#v; want all groups", a.SprintSource(0))
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatalf("json.Marshal(a) error = %#v", err)
+	}
+	var report struct {
+		Total  int
+		Groups []struct {
+			Code     string
+			Count    int
+			Messages []string
+			Error    struct {
+				Message string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("json.Unmarshal(data) error = %#v", err)
+	}
+	if report.Total != 4 || len(report.Groups) != 2 ||
+		report.Groups[0].Count != 3 ||
+		report.Groups[0].Code != tracerr.FingerprintCode(first.Err) ||
+		report.Groups[0].Error.Message != "retry 0" {
+		t.Errorf("json.Marshal(a) = %s", data)
+	}
+
+	a.Reset()
+	if a.Total() != 0 || len(a.Top(0)) != 0 {
+		t.Errorf("a.Reset() left %#v errors in %#v groups", a.Total(), len(a.Top(0)))
+	}
+}
+
+func TestAggregatorLimit(t *testing.T) {
+	a := tracerr.NewAggregator(2)
+	newErrors := []func() error{
+		func() error { return tracerr.New("a") },
+		func() error { return tracerr.New("b") },
+		func() error { return tracerr.New("c") },
+	}
+	for _, i := range []int{0, 0, 1, 2} {
+		a.Add(newErrors[i]())
+	}
+
+	groups := a.Top(0)
+	if len(groups) != 2 {
+		t.Fatalf("len(a.Top(0)) = %#v; want %#v", len(groups), 2)
+	}
+	if groups[0].Err.Error() != "a" || groups[1].Err.Error() != "c" {
+		t.Errorf("a.Top(0) = %#v, %#v; want groups of a and c", groups[0].Err.Error(), groups[1].Err.Error())
+	}
+	summary := strings.Split(a.Sprint(0), "\n")[0]
+	if summary != "4 errors in 2 groups, 1 in evicted groups" {
+		t.Errorf("a.Sprint(0) summary = %#v; want %#v", summary, "4 errors in 2 groups, 1 in evicted groups")
+	}
+}
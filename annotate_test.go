@@ -0,0 +1,55 @@
+package tracerr_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func loadUser(err error) (result error) {
+	defer tracerr.Annotate(&result, "load user %d", 42)
+	return err
+}
+
+func TestAnnotate(t *testing.T) {
+	err := loadUser(io.EOF)
+	if err.Error() != "load user 42: EOF" {
+		t.Errorf("err.Error() = %#v; want %#v", err.Error(), "load user 42: EOF")
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Error("errors.Is(err, io.EOF) = false; want true")
+	}
+	frames := err.(tracerr.Error).StackTrace()
+	if len(frames) == 0 || !strings.HasSuffix(frames[0].Func, ".loadUser") {
+		t.Errorf("err.StackTrace() = %#v; want loadUser on top", frames)
+	}
+
+	defer enableTrail()()
+	origin := tracerr.New("some error")
+	err = loadUser(origin)
+	if err.Error() != "load user 42: some error" {
+		t.Errorf("err.Error() = %#v; want %#v", err.Error(), "load user 42: some error")
+	}
+	if !errors.Is(err, origin) {
+		t.Error("errors.Is(err, origin) = false; want true")
+	}
+	e := err.(tracerr.Error)
+	if len(e.StackTrace()) != len(origin.StackTrace()) || e.StackTrace()[0] != origin.StackTrace()[0] {
+		t.Errorf("err.StackTrace() = %#v; want %#v", e.StackTrace(), origin.StackTrace())
+	}
+	trail := tracerr.Trail(err)
+	if len(trail) != 1 || !strings.HasSuffix(trail[0].Func, ".loadUser") {
+		t.Errorf("tracerr.Trail(err) = %#v; want loadUser", trail)
+	}
+	if tracerr.Trail(origin) != nil {
+		t.Errorf("origin is modified by Annotate: %#v", tracerr.Trail(origin))
+	}
+
+	if err := loadUser(nil); err != nil {
+		t.Errorf("loadUser(nil) = %#v; want nil", err)
+	}
+	tracerr.Annotate(nil, "no error")
+}
@@ -0,0 +1,55 @@
+// Command tracerr works with stack traces produced by tracerr package.
+//
+// Usage:
+//
+//	tracerr [render] [-f] [-lines n] [-color] [-trim prefix] [-root dir] [-exclude regexp] [file]
+//	tracerr symbolize -binary path [encoded ...]
+//
+// Render reads a log file or standard input, finds output of tracerr print functions,
+// Go panics and goroutine dumps, and prints each of them with source fragments.
+// Other lines are passed through untouched.
+// It's a default command.
+//
+// Symbolize resolves stack traces encoded by tracerr.EncodeCallers,
+// which are taken from arguments or found in standard input,
+// and prints them with source fragments.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const usage = `Usage:
+
+	tracerr [render] [-f] [-lines n] [-color] [-trim prefix] [-root dir] [-exclude regexp] [file]
+	tracerr symbolize -binary path [encoded ...]
+
+Run "tracerr <command> -h" for details.
+`
+
+func main() {
+	args := os.Args[1:]
+	command := "render"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "render", "symbolize":
+			command, args = args[0], args[1:]
+		case "help":
+			fmt.Fprint(os.Stdout, usage)
+			return
+		}
+	}
+	var err error
+	switch command {
+	case "render":
+		err = render(args)
+	case "symbolize":
+		err = symbolize(args)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tracerr: %v\n", err)
+		os.Exit(1)
+	}
+}
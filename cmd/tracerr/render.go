@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ztrue/tracerr"
+)
+
+// followInterval is a delay between checks for new lines in follow mode.
+const followInterval = 250 * time.Millisecond
+
+// renderOptions defines how detected stack traces are rendered.
+type renderOptions struct {
+	lines   int
+	color   bool
+	trim    string
+	root    string
+	exclude *regexp.Regexp
+}
+
+func render(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	follow := fs.Bool("f", false, "wait for new lines appended to the file, like tail -f")
+	lines := fs.Int("lines", 6, "number of source lines to display, 0 to hide source")
+	color := fs.Bool("color", isTerminal(os.Stdout), "colorize output")
+	trim := fs.String("trim", "", "prefix to remove from paths of frames")
+	root := fs.String("root", "", "directory to look for source files with relative paths")
+	exclude := fs.String("exclude", "", "regexp of functions and paths of frames to hide")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "Usage: tracerr [render] [flags] [file]\n\n")
+		fmt.Fprint(fs.Output(), "Standard input is read if file is not provided.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	opts := renderOptions{
+		lines: *lines,
+		color: *color,
+		trim:  *trim,
+		root:  *root,
+	}
+	if *exclude != "" {
+		re, err := regexp.Compile(*exclude)
+		if err != nil {
+			return err
+		}
+		opts.exclude = re
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	d := tracerr.NewDetector(out, func(errs []tracerr.Error) string {
+		return renderErrors(errs, opts)
+	})
+
+	if fs.NArg() == 0 {
+		return scan(os.Stdin, d, nil)
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !*follow {
+		return scan(f, d, nil)
+	}
+	return scan(f, d, func() error {
+		return wait(f, d, out)
+	})
+}
+
+// scan passes lines of r to detector.
+// On the end of input it calls wait if provided, and continues reading,
+// otherwise it stops.
+func scan(r io.Reader, d *tracerr.Detector, wait func() error) error {
+	br := bufio.NewReader(r)
+	var partial string
+	for {
+		line, err := br.ReadString('\n')
+		partial += line
+		if err == nil {
+			d.Line(strings.TrimSuffix(strings.TrimSuffix(partial, "\n"), "\r"))
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		if wait == nil {
+			if partial != "" {
+				d.Line(partial)
+			}
+			d.Flush()
+			return nil
+		}
+		if err := wait(); err != nil {
+			return err
+		}
+	}
+}
+
+// wait blocks until file has new data, flushing output if it takes a while.
+// It starts over if file is truncated.
+func wait(f *os.File, d *tracerr.Detector, out *bufio.Writer) error {
+	flushed := false
+	for {
+		time.Sleep(followInterval)
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			_, err := f.Seek(0, io.SeekStart)
+			return err
+		}
+		if info.Size() > offset {
+			return nil
+		}
+		if !flushed {
+			d.Flush()
+			out.Flush()
+			flushed = true
+		}
+	}
+}
+
+func renderErrors(errs []tracerr.Error, opts renderOptions) string {
+	var b strings.Builder
+	for i, err := range errs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		err = transform(err, opts)
+		var output string
+		if opts.color {
+			output = tracerr.SprintSourceColor(err, opts.lines)
+		} else {
+			output = tracerr.SprintSource(err, opts.lines)
+		}
+		// Empty lines after a stack trace are kept from input.
+		b.WriteString(strings.TrimRight(output, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// transform changes paths of frames and removes excluded ones,
+// including frames of goroutine starts and trail.
+func transform(err tracerr.Error, opts renderOptions) tracerr.Error {
+	if opts.trim == "" && opts.root == "" && opts.exclude == nil {
+		return err
+	}
+	return tracerr.MapFrames(err, func(frame tracerr.Frame) (tracerr.Frame, bool) {
+		if opts.exclude != nil && (opts.exclude.MatchString(frame.Func) || opts.exclude.MatchString(frame.Path)) {
+			return frame, false
+		}
+		if opts.trim != "" {
+			frame.Path = strings.TrimPrefix(frame.Path, opts.trim)
+		}
+		if opts.root != "" && !filepath.IsAbs(frame.Path) {
+			frame.Path = filepath.Join(opts.root, frame.Path)
+		}
+		return frame, true
+	})
+}
+
+// isTerminal returns true if f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
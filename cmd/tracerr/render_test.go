@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestTransform(t *testing.T) {
+	err, parseErr := tracerr.Parse(strings.Join([]string{
+		"some error",
+		"/build/src/foo.go:42 main.Foo()",
+		"/usr/local/go/src/runtime/proc.go:250 runtime.main()",
+		"[... 3 frames omitted ...]",
+		"/build/src/main.go:10 main.main()",
+		"started by:",
+		"/build/src/start.go:5 main.start()",
+		"/usr/local/go/src/runtime/proc.go:251 runtime.main()",
+		"returned through:",
+		"/build/src/bar.go:7 main.Bar()",
+		"\tloading bar",
+	}, "\n"))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	opts := renderOptions{
+		trim: "/build/",
+		root: "/home/john",
+	}
+	opts.exclude = mustCompile(t, `^runtime\.`)
+	transformed := transform(err, opts)
+	if transformed.Error() != err.Error() {
+		t.Errorf("transform(err).Error() = %#v; want %#v", transformed.Error(), err.Error())
+	}
+	expected := strings.Join([]string{
+		"some error",
+		"/home/john/src/foo.go:42 main.Foo()",
+		"[... 3 frames omitted ...]",
+		"/home/john/src/main.go:10 main.main()",
+		"started by:",
+		"/home/john/src/start.go:5 main.start()",
+		"returned through:",
+		"/home/john/src/bar.go:7 main.Bar()",
+		"\tloading bar",
+	}, "\n")
+	if output := tracerr.Sprint(transformed); output != expected {
+		t.Errorf("tracerr.Sprint(transform(err)) = %#v; want %#v", output, expected)
+	}
+}
+
+func mustCompile(t *testing.T, expr string) *regexp.Regexp {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return re
+}
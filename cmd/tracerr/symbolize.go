@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/ztrue/tracerr"
+)
+
+// encodedPattern matches output of tracerr.EncodeCallers.
+var encodedPattern = regexp.MustCompile(`tracerr1:[A-Za-z0-9_/=+-]+:[A-Za-z0-9_-]+`)
+
+func symbolize(args []string) error {
+	fs := flag.NewFlagSet("symbolize", flag.ExitOnError)
+	binary := fs.String("binary", "", "path to the executable that produced stack traces")
+	lines := fs.Int("lines", 6, "number of source lines to display, 0 to hide source")
+	color := fs.Bool("color", false, "colorize output")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "Usage: tracerr symbolize -binary path [encoded ...]\n\n")
+		fmt.Fprint(fs.Output(), "Encoded stack traces are read from standard input if not provided.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *binary == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	print := func(encoded string) error {
+		err, symbolizeErr := tracerr.SymbolizeError(*binary, encoded, errors.New(encoded))
+		if symbolizeErr != nil {
+			return symbolizeErr
+		}
+		if *color {
+			fmt.Println(tracerr.SprintSourceColor(err, *lines))
+		} else {
+			fmt.Println(tracerr.SprintSource(err, *lines))
+		}
+		return nil
+	}
+	if fs.NArg() > 0 {
+		for _, encoded := range fs.Args() {
+			if err := print(encoded); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return scanEncoded(os.Stdin, print)
+}
+
+// scanEncoded calls fn for every encoded stack trace found in r.
+func scanEncoded(r io.Reader, fn func(encoded string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		for _, encoded := range encodedPattern.FindAllString(scanner.Text(), -1) {
+			if err := fn(encoded); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,35 @@
+package tracerr_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+// Run with -race flag to detect data races.
+func TestConcurrentStackTrace(t *testing.T) {
+	err := addFrameA("shared error").(tracerr.Error)
+	expected := addFrameA("shared error").(tracerr.Error).StackTrace()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			// Lines of the test frame differ, but the rest of frames is the same.
+			frames := err.StackTrace()
+			if len(frames) != len(expected) || frames[0] != expected[0] {
+				t.Errorf("err.StackTrace() = %#v; want %#v", frames, expected)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			tracerr.Sprint(err)
+		}()
+		go func() {
+			defer wg.Done()
+			tracerr.SprintSourceColor(err)
+		}()
+	}
+	wg.Wait()
+}
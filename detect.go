@@ -0,0 +1,179 @@
+package tracerr
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// callPattern matches a function call in a goroutine dump.
+var callPattern = regexp.MustCompile(`^\S.*\)$`)
+
+// maxPanicMessage is a maximum number of lines between panic message
+// and the first goroutine, otherwise it's not a panic.
+const maxPanicMessage = 20
+
+type blockKind int
+
+const (
+	noBlock blockKind = iota
+	tracerrBlock
+	panicBlock
+)
+
+// Detector finds stack traces in a stream of lines, such as a log:
+// output of print functions, Go panics and goroutine dumps.
+// Stack traces are parsed with Parse or ParsePanic and rendered,
+// other lines are written untouched.
+//
+// It's not safe for concurrent use.
+type Detector struct {
+	out io.Writer
+	// render returns output for a detected stack trace.
+	render func(errs []Error) string
+	// held contains recent lines, which are not written yet,
+	// since they could be a message of a stack trace.
+	held []string
+	// block contains lines of a stack trace being collected.
+	block []string
+	kind  blockKind
+	// blanks contains empty lines after block,
+	// which are not known yet to be a part of it.
+	blanks []string
+	// goroutines is a number of goroutines in panic block.
+	goroutines int
+}
+
+// NewDetector returns a detector, which writes lines to out,
+// replacing stack traces with output of render.
+// Errors passed to render are returned by Parse for output of print functions,
+// or by ParsePanic for panics and goroutine dumps.
+// Output of render should end with a line break.
+func NewDetector(out io.Writer, render func(errs []Error) string) *Detector {
+	return &Detector{
+		out:    out,
+		render: render,
+	}
+}
+
+// Line processes a single line without line break.
+// Lines could be held until it's known whether they are a part of a stack trace.
+func (d *Detector) Line(line string) {
+	stripped := escapePattern.ReplaceAllString(line, "")
+	if d.kind != noBlock {
+		if strings.TrimSpace(stripped) == "" {
+			d.blanks = append(d.blanks, line)
+			return
+		}
+		if d.continues(stripped) {
+			d.block = append(d.block, d.blanks...)
+			d.block = append(d.block, line)
+			d.blanks = nil
+			return
+		}
+		d.endBlock()
+	}
+
+	switch {
+	case framePattern.MatchString(stripped) && !sourceRowPattern.MatchString(stripped):
+		// Message is right before the first frame,
+		// output with source also has an empty line in between.
+		n := 0
+		if len(d.held) > 1 && d.held[len(d.held)-1] == "" {
+			n = 2
+		} else if len(d.held) > 0 {
+			n = 1
+		}
+		d.write(d.held[:len(d.held)-n]...)
+		d.block = append(d.held[len(d.held)-n:len(d.held):len(d.held)], line)
+		d.held = nil
+		d.kind = tracerrBlock
+	case strings.HasPrefix(stripped, "panic: "), strings.HasPrefix(stripped, "fatal error: "):
+		d.write(d.held...)
+		d.held = nil
+		d.block = []string{line}
+		d.kind = panicBlock
+		d.goroutines = 0
+	case goroutinePattern.MatchString(stripped):
+		d.write(d.held...)
+		d.held = nil
+		d.block = []string{line}
+		d.kind = panicBlock
+		d.goroutines = 1
+	default:
+		d.held = append(d.held, line)
+		if len(d.held) > 2 {
+			d.write(d.held[0])
+			d.held = d.held[1:]
+		}
+	}
+}
+
+// Flush renders a stack trace being collected and writes held lines.
+// It should be called on the end of input.
+func (d *Detector) Flush() {
+	if d.kind != noBlock {
+		d.endBlock()
+	}
+	d.write(d.held...)
+	d.held = nil
+}
+
+// continues returns true if line is a part of the current block.
+func (d *Detector) continues(line string) bool {
+	if d.kind == tracerrBlock {
+		return framePattern.MatchString(line) ||
+			sourceRowPattern.MatchString(line) ||
+			repeatsPattern.MatchString(line) ||
+			omittedPattern.MatchString(line) ||
+			strings.HasPrefix(line, "tracerr: ") ||
+			line == trailTitle ||
+			line == spawnTitle ||
+			// Link to source code or a note of trail.
+			strings.HasPrefix(line, "\t")
+	}
+	if goroutinePattern.MatchString(line) {
+		d.goroutines++
+		return true
+	}
+	if d.goroutines == 0 {
+		// Panic message could take a few lines before the first goroutine.
+		return len(d.block)+len(d.blanks) < maxPanicMessage
+	}
+	// Goroutines are separated with empty lines.
+	if len(d.blanks) > 0 {
+		return false
+	}
+	return strings.HasPrefix(line, "\t") ||
+		strings.HasPrefix(line, "created by ") ||
+		elidedPattern.MatchString(line) ||
+		line == additionalElided ||
+		callPattern.MatchString(line)
+}
+
+func (d *Detector) endBlock() {
+	text := strings.Join(d.block, "\n")
+	var errs []Error
+	if d.kind == tracerrBlock {
+		if err, parseErr := Parse(text); parseErr == nil {
+			errs = []Error{err}
+		}
+	} else if d.goroutines > 0 {
+		errs, _ = ParsePanic(text)
+	}
+	if errs == nil {
+		// Not a stack trace after all, write it as is.
+		d.write(d.block...)
+	} else {
+		io.WriteString(d.out, d.render(errs))
+	}
+	d.write(d.blanks...)
+	d.block, d.blanks = nil, nil
+	d.kind = noBlock
+}
+
+func (d *Detector) write(lines ...string) {
+	for _, line := range lines {
+		io.WriteString(d.out, line+"\n")
+	}
+}
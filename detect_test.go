@@ -0,0 +1,78 @@
+package tracerr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestDetector(t *testing.T) {
+	err := tracerr.CustomError(
+		errors.New("some error"),
+		[]tracerr.Frame{
+			{
+				Func: "main.Foo",
+				Line: 42,
+				Path: "/tmp/not_exists.go",
+			},
+			{
+				Func: "main.Bar",
+				Line: 43,
+				Path: "/tmp/not_exists_2.go",
+			},
+		},
+	)
+	input := []string{
+		"2024/01/01 starting",
+		tracerr.Sprint(err),
+		"2024/01/01 retrying",
+		"",
+		tracerr.SprintSourceColor(err),
+		"2024/01/01 crashed",
+		"panic: boom",
+		"",
+		"goroutine 1 [running]:",
+		"main.main()",
+		"\t/tmp/main.go:5 +0x1d",
+		"",
+		"goroutine 5 [select]:",
+		"main.worker(...)",
+		"\t/tmp/worker.go:7",
+		"created by main.main in goroutine 1",
+		"\t/tmp/main.go:4 +0x1a",
+		"exit status 2",
+		"panic: not really",
+		"done",
+	}
+	var out strings.Builder
+	d := tracerr.NewDetector(&out, func(errs []tracerr.Error) string {
+		var rows []string
+		for _, err := range errs {
+			rows = append(rows, "RENDERED "+err.Error()+" "+tracerr.StackTrace(err)[0].String())
+		}
+		return strings.Join(rows, "\n") + "\n"
+	})
+	for _, line := range strings.Split(strings.Join(input, "\n"), "\n") {
+		d.Line(line)
+	}
+	d.Flush()
+	expected := strings.Join([]string{
+		"2024/01/01 starting",
+		"RENDERED some error /tmp/not_exists.go:42 main.Foo()",
+		"2024/01/01 retrying",
+		"",
+		"RENDERED some error /tmp/not_exists.go:42 main.Foo()",
+		"",
+		"2024/01/01 crashed",
+		"RENDERED boom /tmp/main.go:5 main.main()",
+		"RENDERED goroutine 5 [select] /tmp/worker.go:7 main.worker()",
+		"exit status 2",
+		"panic: not really",
+		"done",
+	}, "\n") + "\n"
+	if out.String() != expected {
+		t.Errorf("output = %#v; want %#v", out.String(), expected)
+	}
+}
@@ -7,13 +7,34 @@ package tracerr
 import (
 	"fmt"
 	"runtime"
+	"sync"
 )
 
-// DefaultCap is a default cap for frames array.
+// DefaultCap is an initial size of buffers, which program counters are captured to.
+// Buffers grow if the stack is deeper, and are reused afterwards.
 // It can be changed to number of expected frames
 // for purpose of performance optimisation.
 var DefaultCap = 20
 
+// inlineCap is a number of program counters stored inside the error itself,
+// so errors with shallow stacks take a single allocation.
+const inlineCap = 16
+
+// MaxFrames is a maximum number of frames captured for an error.
+// If the stack is deeper, only MaxFrames-TailFrames frames from the top
+// and TailFrames frames from the bottom are kept,
+// and the number of dropped frames is recorded on the error.
+// This keeps the cost of capturing bounded in runaway recursion.
+// Zero means no limit.
+var MaxFrames = 512
+
+// TailFrames is a number of bottom frames kept when the stack is deeper than MaxFrames.
+var TailFrames = 32
+
+// MaxTrail is a maximum number of points in trail of an error, see Wrap.
+// Zero disables trail, so Wrap returns an existing Error as is.
+var MaxTrail = 0
+
 // Error is an error with stack trace.
 type Error interface {
 	Callers() []uintptr
@@ -29,6 +50,38 @@ type errorData struct {
 	pcs []uintptr
 	// frames contains pre-resolved stack trace.
 	frames []Frame
+	// resolve makes lazy resolving of frames safe for concurrent use.
+	resolve sync.Once
+	// head is a number of program counters captured before the dropped ones.
+	head int
+	// gap is an index of the frame that follows the dropped frames.
+	gap int
+	// dropped is a number of frames dropped from the middle of the stack.
+	dropped int
+	// origin is an error, which stack trace is shared with this one,
+	// if it's created by wrapping an existing error.
+	origin *errorData
+	// trail contains call sites, where an existing error was wrapped.
+	trail []trailPoint
+	// spawn contains stack trace of the goroutine start,
+	// if the stack is captured in a goroutine started by Go or GoCtx.
+	spawn *errorData
+}
+
+// TrailPoint is a call site, where an existing error was wrapped
+// on its way up the stack.
+type TrailPoint struct {
+	Frame
+	// Note is an optional message, see WrapNote.
+	Note string
+}
+
+// trailPoint is a call site in trail.
+// Program counter is resolved to frame lazily, unless frame is restored, see Parse.
+type trailPoint struct {
+	pc    uintptr
+	frame Frame
+	note  string
 }
 
 // CustomError creates an error with provided frames.
@@ -42,11 +95,59 @@ func CustomError(err error, frames []Frame) Error {
 // CustomErrorFromCallers creates an error with provided program counters.
 func CustomErrorFromCallers(err error, pcs []uintptr) Error {
 	return &errorData{
-		err: err,
-		pcs: pcs,
+		err:  err,
+		pcs:  pcs,
+		head: len(pcs),
 	}
 }
 
+// MapFrames returns an error with the same message and wrapped errors as err,
+// but with frames changed by fn, including frames of goroutine starts and trail,
+// see Go and Wrap. A frame is removed if fn returns false.
+// Numbers of frames dropped from the middle of deep stacks are kept.
+func MapFrames(err Error, fn func(frame Frame) (Frame, bool)) Error {
+	if err == nil {
+		return nil
+	}
+	d, ok := err.(*errorData)
+	if !ok {
+		d = CustomError(err.Unwrap(), err.StackTrace()).(*errorData)
+	}
+	e := mapStack(d, d.err, fn)
+	for _, p := range Trail(d) {
+		if frame, ok := fn(p.Frame); ok {
+			e.trail = append(e.trail, trailPoint{frame: frame, note: p.Note})
+		}
+	}
+	return e
+}
+
+// mapStack returns an error with frames of e changed by fn,
+// including frames of goroutine starts.
+func mapStack(e *errorData, err error, fn func(frame Frame) (Frame, bool)) *errorData {
+	at, dropped := e.droppedFrames()
+	frames := e.StackTrace()
+	mapped := &errorData{
+		err:     err,
+		frames:  make([]Frame, 0, len(frames)),
+		dropped: dropped,
+	}
+	for i, frame := range frames {
+		frame, ok := fn(frame)
+		if !ok {
+			continue
+		}
+		if i < at {
+			mapped.gap++
+		}
+		mapped.frames = append(mapped.frames, frame)
+	}
+	if e.spawn != nil {
+		mapped.spawn = mapStack(e.spawn, nil, fn)
+	}
+	return mapped
+}
+
 // Errorf creates new error with stacktrace and formatted message.
 // Formatting works the same way as in fmt.Errorf.
 func Errorf(message string, args ...interface{}) Error {
@@ -59,17 +160,144 @@ func New(message string) Error {
 }
 
 // Wrap adds stacktrace to existing error.
+//
+// If err is already an Error, it's returned as is.
+// If MaxTrail is set, its call site is added to the trail instead,
+// which shows a path the error took back up, see Trail.
+// A new error with the same message and stack trace is returned in this case,
+// so errors.Is should be used to compare it with the original one.
 func Wrap(err error) Error {
 	if err == nil {
 		return nil
 	}
 	e, ok := err.(Error)
 	if ok {
-		return e
+		return addTrail(e, "", 3)
 	}
 	return trace(err, 2)
 }
 
+// WrapNote works the same way as Wrap,
+// and also adds a formatted note to the call site in trail.
+// Formatting works the same way as in fmt.Sprintf.
+// Error message is not changed.
+func WrapNote(err error, format string, args ...interface{}) Error {
+	if err == nil {
+		return nil
+	}
+	note := fmt.Sprintf(format, args...)
+	e, ok := err.(Error)
+	if ok {
+		return addTrail(e, note, 3)
+	}
+	d := trace(err, 2).(*errorData)
+	if MaxTrail > 0 {
+		d.trail = []trailPoint{{pc: callerPC(2), note: note}}
+	}
+	return d
+}
+
+// Annotate prefixes a non-nil error with a formatted message
+// the same way as fmt.Errorf("message: %w", err).
+// It's meant to be deferred with a named result,
+// so every return of function is annotated:
+//
+//	func loadUser(id int) (err error) {
+//		defer tracerr.Annotate(&err, "load user %d", id)
+//		...
+//	}
+//
+// If error has a stack trace, it's kept and the function is added to trail
+// if MaxTrail is set, see Wrap. Otherwise, stack trace is captured in the function.
+func Annotate(errp *error, format string, args ...interface{}) {
+	if errp == nil || *errp == nil {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	switch e := (*errp).(type) {
+	case *errorData:
+		annotated := derive(e, fmt.Errorf("%s: %w", message, e.err))
+		if MaxTrail > 0 && len(e.trail) < MaxTrail {
+			trail := make([]trailPoint, len(e.trail), len(e.trail)+1)
+			copy(trail, e.trail)
+			annotated.trail = append(trail, trailPoint{pc: callerPC(2)})
+		}
+		*errp = annotated
+	case Error:
+		*errp = CustomError(fmt.Errorf("%s: %w", message, e.Unwrap()), e.StackTrace())
+	default:
+		*errp = trace(fmt.Errorf("%s: %w", message, e), 2)
+	}
+}
+
+// Trail returns call sites, where an error was wrapped on its way up,
+// in order they were recorded.
+// It will be empty if err is not of type Error.
+func Trail(err error) []TrailPoint {
+	e, ok := err.(*errorData)
+	if !ok || len(e.trail) == 0 {
+		return nil
+	}
+	trail := make([]TrailPoint, len(e.trail))
+	for i, p := range e.trail {
+		trail[i] = TrailPoint{Frame: p.frame, Note: p.note}
+		if p.pc == 0 {
+			continue
+		}
+		// Call site is the innermost frame, if the call is inlined.
+		if frames := resolveFrames(nil, []uintptr{p.pc}); len(frames) > 0 {
+			trail[i].Frame = frames[0]
+		}
+	}
+	return trail
+}
+
+// restoreTrail returns trail of call sites, which frames are already known,
+// see Parse.
+func restoreTrail(trail []TrailPoint) []trailPoint {
+	if len(trail) == 0 {
+		return nil
+	}
+	restored := make([]trailPoint, len(trail))
+	for i, p := range trail {
+		restored[i] = trailPoint{frame: p.Frame, note: p.Note}
+	}
+	return restored
+}
+
+// addTrail returns a copy of error with a call site added to trail.
+// skip is a number of frames to skip before the call site.
+func addTrail(e Error, note string, skip int) Error {
+	d, ok := e.(*errorData)
+	if !ok || MaxTrail <= 0 || len(d.trail) >= MaxTrail {
+		return e
+	}
+	trail := make([]trailPoint, len(d.trail), len(d.trail)+1)
+	copy(trail, d.trail)
+	derived := derive(d, d.err)
+	derived.trail = append(trail, trailPoint{pc: callerPC(skip), note: note})
+	return derived
+}
+
+// derive returns an error with the same stack trace, trail and goroutine start as e,
+// but with a different original error.
+func derive(e *errorData, err error) *errorData {
+	return &errorData{
+		err:    err,
+		origin: e.stack(),
+		trail:  e.trail,
+		spawn:  e.spawn,
+	}
+}
+
+// callerPC returns a program counter of the caller,
+// skip is the same as in runtime.Callers.
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(skip+1, pcs[:])
+	return pcs[0]
+}
+
 // Unwrap returns the original error.
 func Unwrap(err error) error {
 	if err == nil {
@@ -84,7 +312,22 @@ func Unwrap(err error) error {
 
 // Callers returns raw program counters of the stack trace.
 func (e *errorData) Callers() []uintptr {
-	return e.pcs
+	return e.stack().pcs
+}
+
+// stack returns an error, which stack trace is used.
+func (e *errorData) stack() *errorData {
+	if e.origin != nil {
+		return e.origin
+	}
+	return e
+}
+
+// Is returns true if target is an error with the same stack trace,
+// such as the original error, which this one is wrapped from.
+func (e *errorData) Is(target error) bool {
+	t, ok := target.(*errorData)
+	return ok && t.stack() == e.stack()
 }
 
 // Error returns error message.
@@ -93,25 +336,24 @@ func (e *errorData) Error() string {
 }
 
 // StackTrace resolves and returns the stack trace, caching the result.
+// It's safe to call it from multiple goroutines.
 func (e *errorData) StackTrace() []Frame {
+	s := e.stack()
+	s.resolve.Do(s.resolveFrames)
+	return s.frames
+}
+
+func (e *errorData) resolveFrames() {
 	if e.frames != nil {
-		return e.frames
+		return
 	}
-	cf := runtime.CallersFrames(e.pcs)
 	frames := make([]Frame, 0, len(e.pcs))
-	for {
-		f, more := cf.Next()
-		frames = append(frames, Frame{
-			Func: f.Function,
-			Line: f.Line,
-			Path: f.File,
-		})
-		if !more {
-			break
-		}
+	frames = resolveFrames(frames, e.pcs[:e.head])
+	e.gap = len(frames)
+	if e.head < len(e.pcs) {
+		frames = resolveFrames(frames, e.pcs[e.head:])
 	}
 	e.frames = frames
-	return e.frames
 }
 
 // Unwrap returns the original error.
@@ -119,6 +361,17 @@ func (e *errorData) Unwrap() error {
 	return e.err
 }
 
+// droppedFrames returns a number of frames dropped from the middle of the stack
+// and an index of the frame that follows them.
+func (e *errorData) droppedFrames() (at, count int) {
+	e = e.stack()
+	if e.dropped == 0 {
+		return 0, 0
+	}
+	e.StackTrace()
+	return e.gap, e.dropped
+}
+
 // Frame is a single step in stack trace.
 type Frame struct {
 	// Func contains a function name.
@@ -139,23 +392,140 @@ func StackTrace(err error) []Frame {
 	return e.StackTrace()
 }
 
+// DroppedFrames returns a number of frames, which were not captured
+// because the stack was deeper than MaxFrames.
+// It will be zero if err is not of type Error.
+func DroppedFrames(err error) int {
+	e, ok := err.(*errorData)
+	if !ok {
+		return 0
+	}
+	return e.stack().dropped
+}
+
 // String formats Frame to string.
 func (f Frame) String() string {
 	return fmt.Sprintf("%s:%d %s()", f.Path, f.Line, f.Func)
 }
 
+// inlineError is an error with a shallow stack,
+// which program counters are stored inside it,
+// so it takes a single allocation.
+type inlineError struct {
+	errorData
+	inline [inlineCap]uintptr
+}
+
+// scratchPool contains buffers for capturing program counters.
+// Only used program counters are copied to the error afterwards.
+var scratchPool = sync.Pool{
+	New: func() interface{} {
+		size := DefaultCap
+		if size < 1 {
+			size = 1
+		}
+		pcs := make([]uintptr, size)
+		return &pcs
+	},
+}
+
 func trace(err error, skip int) Error {
-	pcs := make([]uintptr, DefaultCap)
+	scratch := scratchPool.Get().(*[]uintptr)
+	pcs, head, dropped := callers(skip+1, scratch)
+	var e *errorData
+	if len(pcs) <= inlineCap {
+		ie := &inlineError{}
+		ie.pcs = ie.inline[:len(pcs):len(pcs)]
+		e = &ie.errorData
+	} else {
+		e = &errorData{pcs: make([]uintptr, len(pcs))}
+	}
+	e.err = err
+	e.head = head
+	e.dropped = dropped
+	copy(e.pcs, pcs)
+	scratchPool.Put(scratch)
+	return e
+}
+
+// callers captures program counters into scratch buffer, growing it if needed.
+// Frames are counted from skip, the same way as in runtime.Callers.
+// If the stack is deeper than MaxFrames, only its head and tail are captured.
+func callers(skip int, scratch *[]uintptr) (pcs []uintptr, head, dropped int) {
+	limit := MaxFrames
+	size := DefaultCap
+	if size < len(*scratch) {
+		size = len(*scratch)
+	}
+	if limit > 0 && size > limit {
+		size = limit
+	}
 	for {
+		if len(*scratch) < size {
+			*scratch = make([]uintptr, size)
+		}
+		pcs = (*scratch)[:size]
 		n := runtime.Callers(skip+1, pcs)
 		if n < len(pcs) {
-			pcs = pcs[:n]
+			return pcs[:n], n, 0
+		}
+		if limit > 0 && len(pcs) >= limit {
 			break
 		}
-		pcs = make([]uintptr, len(pcs)*2)
+		size = len(pcs) * 2
+		if limit > 0 && size > limit {
+			size = limit
+		}
 	}
-	return &errorData{
-		err: err,
-		pcs: pcs,
+	// Stack is at least as deep as the limit, keep the head and the tail only.
+	tail := TailFrames
+	if tail >= limit {
+		tail = limit - 1
+	}
+	if tail < 0 {
+		tail = 0
+	}
+	head = limit - tail
+	depth := stackDepth(skip+1, limit)
+	if depth <= limit {
+		return pcs, len(pcs), 0
+	}
+	runtime.Callers(skip+1+depth-tail, pcs[head:])
+	return pcs, head, depth - limit
+}
+
+// stackDepth returns a number of frames in the stack of the caller,
+// which is known to be at least min frames deep.
+// Frames are counted from skip, the same way as in runtime.Callers.
+// It takes a logarithmic number of probes, so there is no need
+// to capture program counters of the whole stack.
+func stackDepth(skip, min int) int {
+	var probe [32]uintptr
+	// Depth is within [lo, hi), hi is unknown while negative.
+	lo, hi := min, -1
+	step := min
+	for {
+		var offset int
+		if hi < 0 {
+			offset = lo + step
+			step *= 2
+		} else {
+			offset = (lo + hi) / 2
+		}
+		n := runtime.Callers(skip+1+offset, probe[:])
+		if n == 0 {
+			hi = offset
+			if hi <= lo {
+				return lo
+			}
+			continue
+		}
+		if n < len(probe) {
+			return offset + n
+		}
+		lo = offset + len(probe)
+		if hi >= 0 && lo >= hi {
+			return lo
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package tracerr_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -17,6 +18,7 @@ func BenchmarkNew(b *testing.B) {
 			if depth < 1 {
 				panic("number of frames is negative")
 			}
+			b.ReportAllocs()
 			b.ResetTimer()
 
 			for i := 0; i < b.N; i++ {
@@ -26,6 +28,34 @@ func BenchmarkNew(b *testing.B) {
 	}
 }
 
+func BenchmarkNewParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			addFrames(5, "test error")
+		}
+	})
+}
+
+func BenchmarkWrap(b *testing.B) {
+	err := errors.New("test error")
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tracerr.Wrap(err)
+	}
+}
+
+func BenchmarkStackTrace(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		err := addFrames(5, "test error").(tracerr.Error)
+		err.StackTrace()
+	}
+}
+
 func addFrames(depth int, message string) error {
 	if depth <= 1 {
 		return tracerr.New(message)
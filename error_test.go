@@ -272,6 +272,62 @@ func TestDeepStack(t *testing.T) {
 	}
 }
 
+func TestMaxFrames(t *testing.T) {
+	var recurse func(n int) error
+	recurse = func(n int) error {
+		if n == 0 {
+			return tracerr.New("deep error")
+		}
+		return recurse(n - 1)
+	}
+
+	defer func(maxFrames, tailFrames int) {
+		tracerr.MaxFrames = maxFrames
+		tracerr.TailFrames = tailFrames
+	}(tracerr.MaxFrames, tracerr.TailFrames)
+	tracerr.MaxFrames = 0
+	full := recurse(200).(tracerr.Error).StackTrace()
+
+	tracerr.MaxFrames = 40
+	tracerr.TailFrames = 5
+	err := recurse(200).(tracerr.Error)
+	frames := err.StackTrace()
+	if len(frames) != 40 {
+		t.Fatalf("len(err.StackTrace()) = %#v; want %#v", len(frames), 40)
+	}
+	dropped := tracerr.DroppedFrames(err)
+	if dropped != len(full)-40 {
+		t.Errorf("tracerr.DroppedFrames(err) = %#v; want %#v", dropped, len(full)-40)
+	}
+	for i := 0; i < 35; i++ {
+		if frames[i] != full[i] {
+			t.Errorf("frames[%#v] = %#v; want %#v", i, frames[i], full[i])
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		// Lines differ for TestMaxFrames, so compare functions only.
+		if frames[len(frames)-i].Func != full[len(full)-i].Func {
+			t.Errorf(
+				"frames[%#v].Func = %#v; want %#v",
+				len(frames)-i, frames[len(frames)-i].Func, full[len(full)-i].Func,
+			)
+		}
+	}
+
+	output := tracerr.Sprint(err)
+	omitted := fmt.Sprintf("[... %d frames omitted ...]", dropped)
+	if !strings.Contains(output, "\n"+omitted+"\n") {
+		t.Errorf("tracerr.Sprint(err) = %#v; want to contain %#v", output, omitted)
+	}
+
+	if tracerr.DroppedFrames(recurse(10)) != 0 {
+		t.Errorf("tracerr.DroppedFrames(recurse(10)) != 0")
+	}
+	if tracerr.DroppedFrames(errors.New("regular error")) != 0 {
+		t.Errorf("tracerr.DroppedFrames(regular error) != 0")
+	}
+}
+
 func TestErrorNil(t *testing.T) {
 	wrapped := wrapError(nil)
 	if wrapped != nil {
@@ -345,3 +401,33 @@ func TestUnwrap(t *testing.T) {
 func wrapError(err error) error {
 	return tracerr.Wrap(err)
 }
+
+func TestMapFrames(t *testing.T) {
+	errs, parseErr := tracerr.ParsePanic(strings.Join([]string{
+		"panic: some panic",
+		"",
+		"goroutine 1 [running]:",
+		"main.foo(...)",
+		"\t/tmp/foo.go:5 +0x1d",
+		"main.main()",
+		"\t/tmp/main.go:9 +0x25",
+	}, "\n"))
+	if parseErr != nil || len(errs) != 1 {
+		t.Fatalf("tracerr.ParsePanic() = %#v, %#v", errs, parseErr)
+	}
+	mapped := tracerr.MapFrames(errs[0], func(frame tracerr.Frame) (tracerr.Frame, bool) {
+		frame.Path = strings.TrimPrefix(frame.Path, "/tmp/")
+		return frame, frame.Func != "main.foo"
+	})
+	expected := tracerr.Frame{Func: "main.main", Line: 9, Path: "main.go"}
+	if frames := mapped.StackTrace(); len(frames) != 1 || frames[0] != expected {
+		t.Errorf("mapped.StackTrace() = %#v; want %#v", frames, []tracerr.Frame{expected})
+	}
+	var g *tracerr.Goroutine
+	if !errors.As(mapped, &g) || g.Panic != "some panic" {
+		t.Errorf("mapped = %#v; want *tracerr.Goroutine", mapped)
+	}
+	if tracerr.MapFrames(nil, nil) != nil {
+		t.Error("tracerr.MapFrames(nil, fn) != nil")
+	}
+}
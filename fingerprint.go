@@ -0,0 +1,92 @@
+package tracerr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FingerprintIgnoreLines excludes line numbers from fingerprints,
+// so they stay the same when unrelated code above is changed.
+var FingerprintIgnoreLines = false
+
+// ShowFingerprint enables output of a fingerprint code in the header line,
+// see FingerprintCode.
+var ShowFingerprint = false
+
+// closurePattern matches numbering of closures, such as ".func1.2".
+var closurePattern = regexp.MustCompile(`\.func\d+(?:\.\d+)*`)
+
+// Fingerprint returns a hash of the stack trace where the error was created,
+// which identifies a place of failure and could be used to group identical errors.
+//
+// Error message is not included, since it often contains variable data.
+// Frames of runtime are skipped, numbering of closures
+// and directories of paths are ignored, so fingerprint is the same
+// for different builds and machines.
+// See also FingerprintIgnoreLines.
+//
+// If err wraps a chain of errors with stack traces,
+// the innermost one is used.
+// Empty string is returned if there is no stack trace.
+func Fingerprint(err error) string {
+	frames := originFrames(err)
+	if len(frames) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, frame := range frames {
+		if strings.HasPrefix(frame.Func, "runtime.") {
+			continue
+		}
+		h.Write([]byte(closurePattern.ReplaceAllString(frame.Func, ".func")))
+		h.Write([]byte{0})
+		h.Write([]byte(path.Base(filepath.ToSlash(frame.Path))))
+		if !FingerprintIgnoreLines {
+			h.Write([]byte{0})
+			h.Write([]byte(strconv.Itoa(frame.Line)))
+		}
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// FingerprintCode returns a short form of Fingerprint
+// that is easy to read and search for, such as "3F2A-91C0".
+// Empty string is returned if there is no stack trace.
+func FingerprintCode(err error) string {
+	return fingerprintCode(Fingerprint(err))
+}
+
+func fingerprintCode(fingerprint string) string {
+	if fingerprint == "" {
+		return ""
+	}
+	code := strings.ToUpper(fingerprint[:8])
+	return code[:4] + "-" + code[4:]
+}
+
+// originFrames returns a stack trace of the innermost error in chain.
+func originFrames(err error) []Frame {
+	if e := originError(err); e != nil {
+		return e.StackTrace()
+	}
+	return nil
+}
+
+// originError returns the innermost error in chain that has a stack trace.
+func originError(err error) Error {
+	var origin Error
+	for err != nil {
+		if e, ok := err.(Error); ok && len(e.StackTrace()) > 0 {
+			origin = e
+		}
+		err = errors.Unwrap(err)
+	}
+	return origin
+}
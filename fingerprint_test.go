@@ -0,0 +1,104 @@
+package tracerr_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestFingerprint(t *testing.T) {
+	var errs []error
+	for i := 0; i < 2; i++ {
+		errs = append(errs, tracerr.Errorf("error %d", i))
+	}
+	other := tracerr.New("error 0")
+	if tracerr.Fingerprint(errs[0]) == "" {
+		t.Fatalf("tracerr.Fingerprint(errs[0]) = %#v; want not empty", "")
+	}
+	if tracerr.Fingerprint(errs[0]) != tracerr.Fingerprint(errs[1]) {
+		t.Errorf(
+			"tracerr.Fingerprint(errs[0]) = %#v; want %#v",
+			tracerr.Fingerprint(errs[0]), tracerr.Fingerprint(errs[1]),
+		)
+	}
+	if tracerr.Fingerprint(errs[0]) == tracerr.Fingerprint(other) {
+		t.Errorf("tracerr.Fingerprint(other) = %#v; want different", tracerr.Fingerprint(other))
+	}
+	wrapped := fmt.Errorf("wrapped: %w", errs[0])
+	if tracerr.Fingerprint(wrapped) != tracerr.Fingerprint(errs[0]) {
+		t.Errorf(
+			"tracerr.Fingerprint(wrapped) = %#v; want %#v",
+			tracerr.Fingerprint(wrapped), tracerr.Fingerprint(errs[0]),
+		)
+	}
+	if fingerprint := tracerr.Fingerprint(errors.New("regular error")); fingerprint != "" {
+		t.Errorf("tracerr.Fingerprint(regular error) = %#v; want %#v", fingerprint, "")
+	}
+	if fingerprint := tracerr.Fingerprint(nil); fingerprint != "" {
+		t.Errorf("tracerr.Fingerprint(nil) = %#v; want %#v", fingerprint, "")
+	}
+}
+
+func TestFingerprintNormalized(t *testing.T) {
+	customError := func(frames ...tracerr.Frame) error {
+		return tracerr.CustomError(errors.New("some error"), frames)
+	}
+	base := customError(
+		tracerr.Frame{Func: "main.foo.func1", Line: 10, Path: "/home/john/app/main.go"},
+		tracerr.Frame{Func: "main.main", Line: 20, Path: "/home/john/app/main.go"},
+	)
+	same := customError(
+		tracerr.Frame{Func: "main.foo.func2.1", Line: 10, Path: "/build/app/main.go"},
+		tracerr.Frame{Func: "main.main", Line: 20, Path: "/build/app/main.go"},
+		tracerr.Frame{Func: "runtime.main", Line: 250, Path: "/usr/local/go/src/runtime/proc.go"},
+		tracerr.Frame{Func: "runtime.goexit", Line: 1650, Path: "/usr/local/go/src/runtime/asm_amd64.s"},
+	)
+	moved := customError(
+		tracerr.Frame{Func: "main.foo.func1", Line: 12, Path: "/home/john/app/main.go"},
+		tracerr.Frame{Func: "main.main", Line: 22, Path: "/home/john/app/main.go"},
+	)
+	if tracerr.Fingerprint(same) != tracerr.Fingerprint(base) {
+		t.Errorf("tracerr.Fingerprint(same) = %#v; want %#v", tracerr.Fingerprint(same), tracerr.Fingerprint(base))
+	}
+	if tracerr.Fingerprint(moved) == tracerr.Fingerprint(base) {
+		t.Errorf("tracerr.Fingerprint(moved) = %#v; want different", tracerr.Fingerprint(moved))
+	}
+	tracerr.FingerprintIgnoreLines = true
+	defer func() {
+		tracerr.FingerprintIgnoreLines = false
+	}()
+	if tracerr.Fingerprint(moved) != tracerr.Fingerprint(base) {
+		t.Errorf(
+			"tracerr.Fingerprint(moved) with FingerprintIgnoreLines = %#v; want %#v",
+			tracerr.Fingerprint(moved), tracerr.Fingerprint(base),
+		)
+	}
+}
+
+func TestFingerprintCode(t *testing.T) {
+	err := tracerr.New("some error")
+	code := tracerr.FingerprintCode(err)
+	if !regexp.MustCompile(`^[0-9A-F]{4}-[0-9A-F]{4}$`).MatchString(code) {
+		t.Errorf("tracerr.FingerprintCode(err) = %#v; want XXXX-XXXX", code)
+	}
+	if !strings.HasPrefix(tracerr.Fingerprint(err), strings.ToLower(strings.Replace(code, "-", "", 1))) {
+		t.Errorf("tracerr.FingerprintCode(err) = %#v; want prefix of %#v", code, tracerr.Fingerprint(err))
+	}
+
+	tracerr.ShowFingerprint = true
+	defer func() {
+		tracerr.ShowFingerprint = false
+	}()
+	header := strings.Split(tracerr.Sprint(err), "\n")[0]
+	if header != "some error ["+code+"]" {
+		t.Errorf("tracerr.Sprint(err) header = %#v; want %#v", header, "some error ["+code+"]")
+	}
+	header = strings.Split(tracerr.Sprint(errors.New("regular error")), "\n")[0]
+	if header != "regular error" {
+		t.Errorf("tracerr.Sprint(regular error) header = %#v; want %#v", header, "regular error")
+	}
+}
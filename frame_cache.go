@@ -0,0 +1,141 @@
+package tracerr
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// FrameCacheSize is a maximum number of program counters,
+// which resolved frames are cached.
+// Errors are usually created at the same places over and over again,
+// so cache makes resolving of their stack traces much cheaper.
+// Zero disables caching.
+var FrameCacheSize = 4096
+
+// CacheStats contains statistics of frames cache.
+type CacheStats struct {
+	// Hits is a number of program counters resolved from cache.
+	Hits uint64
+	// Misses is a number of program counters resolved by runtime.
+	Misses uint64
+	// Size is a number of program counters in cache.
+	Size int
+}
+
+var (
+	frameCacheHits   uint64
+	frameCacheMisses uint64
+)
+
+// frameCache maps a program counter to frames it expands to,
+// there is more than one frame if the call is inlined.
+var frameCache = map[uintptr][]cachedFrame{}
+
+var frameCacheMutex sync.RWMutex
+
+type cachedFrame struct {
+	frame Frame
+	// pc is a program counter of the frame, as runtime.Callers returns it.
+	pc uintptr
+}
+
+// FrameCacheStats returns statistics of frames cache.
+func FrameCacheStats() CacheStats {
+	frameCacheMutex.RLock()
+	size := len(frameCache)
+	frameCacheMutex.RUnlock()
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&frameCacheHits),
+		Misses: atomic.LoadUint64(&frameCacheMisses),
+		Size:   size,
+	}
+}
+
+func resolveFrames(frames []Frame, pcs []uintptr) []Frame {
+	if FrameCacheSize <= 0 || len(pcs) == 0 {
+		return resolveFramesUncached(frames, pcs)
+	}
+	frameCacheMutex.RLock()
+	for i := 0; i < len(pcs); {
+		pc := pcs[i]
+		expanded, ok := frameCache[pc]
+		if ok {
+			atomic.AddUint64(&frameCacheHits, 1)
+		} else {
+			frameCacheMutex.RUnlock()
+			atomic.AddUint64(&frameCacheMisses, 1)
+			expanded = expandPC(pc)
+			storeFrames(pc, expanded)
+			frameCacheMutex.RLock()
+		}
+		i++
+		for j, f := range expanded {
+			// Callers returns a separate program counter for every inlined frame,
+			// it's already resolved as a part of expansion.
+			if j > 0 && i < len(pcs) && pcs[i] == f.pc {
+				i++
+			}
+			frames = append(frames, f.frame)
+		}
+	}
+	frameCacheMutex.RUnlock()
+	return frames
+}
+
+func resolveFramesUncached(frames []Frame, pcs []uintptr) []Frame {
+	cf := runtime.CallersFrames(pcs)
+	for {
+		f, more := cf.Next()
+		frames = append(frames, Frame{
+			Func: f.Function,
+			Line: f.Line,
+			Path: f.File,
+		})
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// expandPC resolves a single program counter to frames,
+// including all frames inlined at that point.
+func expandPC(pc uintptr) []cachedFrame {
+	// Runtime expands inlined frames only if the next program counter
+	// doesn't belong to them, which is never the case for invalid zero one.
+	cf := runtime.CallersFrames([]uintptr{pc, 0})
+	var expanded []cachedFrame
+	for {
+		f, more := cf.Next()
+		if f.PC != 0 || f.Function != "" {
+			expanded = append(expanded, cachedFrame{
+				frame: Frame{
+					Func: f.Function,
+					Line: f.Line,
+					Path: f.File,
+				},
+				pc: f.PC + 1,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return expanded
+}
+
+func storeFrames(pc uintptr, expanded []cachedFrame) {
+	frameCacheMutex.Lock()
+	defer frameCacheMutex.Unlock()
+	if len(frameCache) >= FrameCacheSize {
+		// Map iteration order is random, so it evicts a random entry.
+		for k := range frameCache {
+			delete(frameCache, k)
+			if len(frameCache) < FrameCacheSize {
+				break
+			}
+		}
+	}
+	frameCache[pc] = expanded
+}
@@ -0,0 +1,121 @@
+package tracerr_test
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestFrameCache(t *testing.T) {
+	var errs []tracerr.Error
+	for i := 0; i < 2; i++ {
+		errs = append(errs, addFrameA("cached error").(tracerr.Error))
+	}
+	errs[0].StackTrace()
+	stats := tracerr.FrameCacheStats()
+	if stats.Size == 0 {
+		t.Errorf("tracerr.FrameCacheStats().Size = 0; want > 0")
+	}
+	errs[1].StackTrace()
+	after := tracerr.FrameCacheStats()
+	if after.Hits <= stats.Hits {
+		t.Errorf(
+			"tracerr.FrameCacheStats().Hits = %#v; want > %#v",
+			after.Hits, stats.Hits,
+		)
+	}
+	if after.Misses != stats.Misses {
+		t.Errorf(
+			"tracerr.FrameCacheStats().Misses = %#v; want %#v",
+			after.Misses, stats.Misses,
+		)
+	}
+
+	tracerr.FrameCacheSize = 0
+	defer func() {
+		tracerr.FrameCacheSize = 4096
+	}()
+	err := errs[0]
+	uncached := tracerr.CustomErrorFromCallers(err, err.Callers()).StackTrace()
+	tracerr.FrameCacheSize = 4096
+	cached := tracerr.CustomErrorFromCallers(err, err.Callers()).StackTrace()
+	assertFrames(t, cached, uncached)
+}
+
+var fakePC uintptr
+
+func TestFrameCacheSize(t *testing.T) {
+	tracerr.FrameCacheSize = 2
+	defer func() {
+		tracerr.FrameCacheSize = 4096
+	}()
+	// Invalid program counters, which are never cached yet.
+	pcs := make([]uintptr, 3)
+	for i := range pcs {
+		fakePC++
+		pcs[i] = fakePC
+	}
+	tracerr.CustomErrorFromCallers(errors.New("fake"), pcs).StackTrace()
+	if size := tracerr.FrameCacheStats().Size; size > 2 {
+		t.Errorf("tracerr.FrameCacheStats().Size = %#v; want <= 2", size)
+	}
+}
+
+func TestFrameCacheInlined(t *testing.T) {
+	pcs := inlinedError().Callers()
+	// Keep only real program counters, as they appear in printed stack traces,
+	// which is the first one of each physical frame.
+	var real []uintptr
+	var entry uintptr
+	for _, pc := range pcs {
+		f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if f.Entry != entry {
+			real = append(real, pc)
+		}
+		entry = f.Entry
+	}
+	if len(real) == len(pcs) {
+		t.Skip("no inlined frames")
+	}
+	var expected []tracerr.Frame
+	cf := runtime.CallersFrames(real)
+	for {
+		f, more := cf.Next()
+		expected = append(expected, tracerr.Frame{
+			Func: f.Function,
+			Line: f.Line,
+			Path: f.File,
+		})
+		if !more {
+			break
+		}
+	}
+	for i := 0; i < 2; i++ {
+		err := tracerr.CustomErrorFromCallers(errors.New("inlined"), real)
+		assertFrames(t, err.StackTrace(), expected)
+		err = tracerr.CustomErrorFromCallers(errors.New("inlined"), pcs)
+		assertFrames(t, err.StackTrace(), expected)
+	}
+}
+
+func inlinedError() tracerr.Error {
+	return inlinedErrorA()
+}
+
+func inlinedErrorA() tracerr.Error {
+	return tracerr.New("inlined error")
+}
+
+func assertFrames(t *testing.T, frames, expected []tracerr.Frame) {
+	t.Helper()
+	if len(frames) != len(expected) {
+		t.Fatalf("len(frames) = %#v; want %#v", len(frames), len(expected))
+	}
+	for i := range expected {
+		if frames[i] != expected[i] {
+			t.Errorf("frames[%#v] = %#v; want %#v", i, frames[i], expected[i])
+		}
+	}
+}
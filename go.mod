@@ -1 +1,3 @@
 module github.com/ztrue/tracerr
+
+go 1.21
@@ -0,0 +1,136 @@
+package tracerr
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// PrintGoroutine prints error in the format of Go runtime panic,
+// see SprintGoroutine.
+func PrintGoroutine(err error) {
+	fmt.Println(SprintGoroutine(err))
+}
+
+// SprintGoroutine returns error in the format of Go runtime panic,
+// so tools that understand goroutine dumps could consume it unchanged:
+//
+//	panic: some error
+//
+//	goroutine 1 [running]:
+//	main.read(...)
+//		/src/github.com/john/doe/foobar.go:42 +0x1d
+//
+// Error message is shown as a panic message.
+// Arguments of functions are not known, so they are always shown as "...".
+// Offsets from function entry are only shown for errors
+// with program counters, they are missing in custom errors.
+func SprintGoroutine(err error) string {
+	if err == nil {
+		return ""
+	}
+	e, ok := err.(Error)
+	if !ok {
+		return err.Error()
+	}
+	rows := []string{
+		"panic: " + e.Error(),
+		"",
+		"goroutine 1 [running]:",
+	}
+	at, dropped := 0, 0
+	if d, ok := e.(*errorData); ok {
+		at, dropped = d.droppedFrames()
+	}
+	frames := goroutineFrames(e)
+	for i, frame := range frames {
+		if dropped > 0 && i == at {
+			rows = append(rows, fmt.Sprintf("...%d frames elided...", dropped))
+		}
+		// Runtime doesn't show it.
+		if frame.Func == "runtime.goexit" {
+			continue
+		}
+		rows = append(rows, frame.Func+"(...)")
+		location := fmt.Sprintf("\t%s:%d", frame.Path, frame.Line)
+		if frame.offset > 0 {
+			location += fmt.Sprintf(" +0x%x", frame.offset)
+		}
+		rows = append(rows, location)
+	}
+	// Frames could be dropped after the last kept one, such as without tail.
+	if dropped > 0 && at >= len(frames) {
+		rows = append(rows, fmt.Sprintf("...%d frames elided...", dropped))
+	}
+	if stacks := StartedBy(e); len(stacks) > 0 && len(stacks[0]) > 0 {
+		creator := stacks[0][0]
+		rows = append(rows, "created by "+creator.Func, fmt.Sprintf("\t%s:%d", creator.Path, creator.Line))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// goroutineFrame is a frame with an offset of program counter from function entry.
+type goroutineFrame struct {
+	Frame
+	offset uintptr
+}
+
+// goroutineFrames returns frames of an error with offsets if possible.
+func goroutineFrames(e Error) []goroutineFrame {
+	frames := e.StackTrace()
+	pcs := e.Callers()
+	// Offsets are only known if frames are resolved from program counters.
+	result := make([]goroutineFrame, 0, len(frames))
+	d, ok := e.(*errorData)
+	if ok {
+		d = d.stack()
+	}
+	if ok && len(pcs) > 0 {
+		result = appendGoroutineFrames(result, pcs[:d.head])
+		if d.head < len(pcs) {
+			result = appendGoroutineFrames(result, pcs[d.head:])
+		}
+		if len(result) == len(frames) {
+			return result
+		}
+		result = result[:0]
+	}
+	for _, frame := range frames {
+		result = append(result, goroutineFrame{Frame: frame})
+	}
+	return result
+}
+
+func appendGoroutineFrames(result []goroutineFrame, pcs []uintptr) []goroutineFrame {
+	cf := runtime.CallersFrames(pcs)
+	// pc is a program counter of the physical frame,
+	// which is the first one of inlined calls.
+	var pc uintptr
+	for {
+		f, more := cf.Next()
+		frame := goroutineFrame{
+			Frame: Frame{
+				Func: f.Function,
+				Line: f.Line,
+				Path: f.File,
+			},
+		}
+		if pc == 0 {
+			// The same as runtime, show return address instead of call instruction.
+			pc = f.PC + 1
+		}
+		// Runtime shows an offset only once for inlined calls,
+		// in the frame they are inlined to.
+		if f.Func != nil {
+			if pc > f.Entry {
+				frame.offset = pc - f.Entry
+			}
+			pc = 0
+		}
+		result = append(result, frame)
+		if !more {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+package tracerr_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestSprintGoroutine(t *testing.T) {
+	err := addFrameA("some error")
+	output := tracerr.SprintGoroutine(err)
+	rows := strings.Split(output, "\n")
+	expectedRows := []string{
+		"panic: some error",
+		"",
+		"goroutine 1 [running]:",
+		"github.com/ztrue/tracerr_test.addFrameC(...)",
+	}
+	if len(rows) < len(expectedRows)+1 {
+		t.Fatalf("tracerr.SprintGoroutine(err) = %#v", output)
+	}
+	for i, expectedRow := range expectedRows {
+		if rows[i] != expectedRow {
+			t.Errorf("rows[%#v] = %#v; want %#v", i, rows[i], expectedRow)
+		}
+	}
+	funcRow := regexp.MustCompile(`^[^\t].*\(\.\.\.\)$`)
+	locationRow := regexp.MustCompile(`^\t.+\.(go|s):\d+( \+0x[0-9a-f]+)?$`)
+	offsets := 0
+	for i := 3; i < len(rows); i += 2 {
+		if !funcRow.MatchString(rows[i]) {
+			t.Errorf("rows[%#v] = %#v; want function", i, rows[i])
+		}
+		if !locationRow.MatchString(rows[i+1]) {
+			t.Errorf("rows[%#v] = %#v; want location", i+1, rows[i+1])
+		}
+		if strings.Contains(rows[i+1], " +0x") {
+			offsets++
+		}
+	}
+	if offsets == 0 {
+		t.Errorf("tracerr.SprintGoroutine(err) = %#v; want offsets", output)
+	}
+	if strings.Contains(output, "runtime.goexit") {
+		t.Errorf("tracerr.SprintGoroutine(err) = %#v; want no runtime.goexit", output)
+	}
+	output = captureOutput(func() {
+		tracerr.PrintGoroutine(err)
+	})
+	if output != tracerr.SprintGoroutine(err)+"\n" {
+		t.Errorf("tracerr.PrintGoroutine(err) = %#v", output)
+	}
+}
+
+func TestSprintGoroutineCustom(t *testing.T) {
+	err := tracerr.CustomError(
+		errors.New("some error"),
+		[]tracerr.Frame{
+			{
+				Func: "main.foo",
+				Line: 42,
+				Path: "/src/github.com/john/doe/foobar.go",
+			},
+			{
+				Func: "main.(*Reader).bar",
+				Line: 43,
+				Path: "/src/github.com/john/doe/bazqux.go",
+			},
+		},
+	)
+	expectedRows := []string{
+		"panic: some error",
+		"",
+		"goroutine 1 [running]:",
+		"main.foo(...)",
+		"\t/src/github.com/john/doe/foobar.go:42",
+		"main.(*Reader).bar(...)",
+		"\t/src/github.com/john/doe/bazqux.go:43",
+	}
+	expected := strings.Join(expectedRows, "\n")
+	if output := tracerr.SprintGoroutine(err); output != expected {
+		t.Errorf("tracerr.SprintGoroutine(err) = %#v; want %#v", output, expected)
+	}
+	if output := tracerr.SprintGoroutine(nil); output != "" {
+		t.Errorf("tracerr.SprintGoroutine(nil) = %#v; want %#v", output, "")
+	}
+	regular := errors.New("regular error")
+	if output := tracerr.SprintGoroutine(regular); output != "regular error" {
+		t.Errorf("tracerr.SprintGoroutine(regular) = %#v; want %#v", output, "regular error")
+	}
+}
+
+func TestSprintGoroutineDropped(t *testing.T) {
+	var recurse func(n int) error
+	recurse = func(n int) error {
+		if n == 0 {
+			return tracerr.New("deep error")
+		}
+		return recurse(n - 1)
+	}
+	defer func(maxFrames, tailFrames int) {
+		tracerr.MaxFrames = maxFrames
+		tracerr.TailFrames = tailFrames
+	}(tracerr.MaxFrames, tracerr.TailFrames)
+	tracerr.MaxFrames = 20
+	tracerr.TailFrames = 5
+	err := recurse(100)
+	output := tracerr.SprintGoroutine(err)
+	rows := strings.Split(output, "\n")
+	expected := regexp.MustCompile(`^\.\.\.\d+ frames elided\.\.\.$`)
+	// Elided frames follow the header and 15 head frames.
+	i := 3 + 15*2
+	if len(rows) <= i || !expected.MatchString(rows[i]) {
+		t.Errorf("tracerr.SprintGoroutine(err) = %#v; want elided frames at row %d", output, i)
+	}
+
+	// Frames are dropped after the last one.
+	decoded, decodeErr := tracerr.UnmarshalJSON([]byte(
+		`{"message":"some error","frames":[{"func":"main.main","line":1,"path":"main.go"}],"dropped":3,"dropped_at":1}`,
+	))
+	if decodeErr != nil {
+		t.Fatalf("tracerr.UnmarshalJSON() error = %v", decodeErr)
+	}
+	output = tracerr.SprintGoroutine(decoded)
+	if !strings.HasSuffix(output, "\nmain.main(...)\n\tmain.go:1\n...3 frames elided...") {
+		t.Errorf("tracerr.SprintGoroutine(decoded) = %#v; want elided frames at the end", output)
+	}
+}
@@ -0,0 +1,105 @@
+package tracerr
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// htmlStyle is embedded into HTML output, so it doesn't depend on a page.
+const htmlStyle = `<style>
+.tracerr { font-family: sans-serif; color: #222; }
+.tracerr-message { font-size: 1.2em; font-weight: bold; white-space: pre-wrap; margin: 0.5em 0; }
+.tracerr-chain, .tracerr-note { color: #777; margin: 0.3em 0; }
+.tracerr-warning { color: #a60; margin: 0.3em 0 0.3em 1em; }
+.tracerr-frame { margin: 0.3em 0; font-family: monospace; }
+.tracerr-frame summary { cursor: pointer; font-weight: bold; }
+.tracerr-source { margin: 0.3em 0; padding: 0.5em 0; background: #f6f6f6; overflow-x: auto; }
+.tracerr-line { display: block; padding: 0 0.5em; }
+.tracerr-traced { background: #fde2e2; color: #b00; }
+.tracerr-trail { margin-top: 0.8em; }
+.tracerr-num { color: #999; padding-right: 1em; user-select: none; }
+.tracerr-traced .tracerr-num { color: #b00; }
+</style>
+`
+
+// SprintHTML returns error output as an HTML fragment,
+// which contains message, wrapped errors, frames and source fragments.
+// Number of source lines is defined by the same rules as in PrintSource,
+// pass 0 to output frames without source.
+//
+// Frames with source fragments are collapsible,
+// traced lines are highlighted.
+// Output is self-contained: it includes its own styles
+// and all the text is escaped.
+func SprintHTML(err error, nums ...int) string {
+	return RenderHTML(BuildReport(err, NewReportOptions(nums...)))
+}
+
+// RenderHTML returns report in the format of SprintHTML.
+func RenderHTML(r *Report) string {
+	if r == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`<div class="tracerr">` + "\n" + htmlStyle)
+	fmt.Fprintf(&b, "<div class=\"tracerr-message\">%s</div>\n", html.EscapeString(r.Message))
+	for _, message := range r.Chain {
+		fmt.Fprintf(&b, "<div class=\"tracerr-chain\">caused by: %s</div>\n", html.EscapeString(message))
+	}
+	for _, f := range r.Frames {
+		if f.Note != "" {
+			fmt.Fprintf(&b, "<div class=\"tracerr-note\">%s</div>\n", html.EscapeString(f.Note))
+			continue
+		}
+		title := html.EscapeString(f.Frame.String())
+		if f.URL != "" {
+			title = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(f.URL), title)
+		}
+		if !r.WithSource || f.Repeated {
+			fmt.Fprintf(&b, "<div class=\"tracerr-frame\">%s</div>\n", title)
+			continue
+		}
+		fmt.Fprintf(&b, "<details class=\"tracerr-frame\" open><summary>%s</summary>\n", title)
+		writeHTMLSource(&b, f)
+		b.WriteString("</details>\n")
+	}
+	if len(r.Trail) > 0 {
+		b.WriteString(`<div class="tracerr-trail">` + "\n")
+		b.WriteString("<div class=\"tracerr-note\">returned through:</div>\n")
+		for _, p := range r.Trail {
+			fmt.Fprintf(&b, "<div class=\"tracerr-frame\">%s</div>\n", html.EscapeString(p.Frame.String()))
+			if p.Note != "" {
+				fmt.Fprintf(&b, "<div class=\"tracerr-note\">%s</div>\n", html.EscapeString(p.Note))
+			}
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</div>")
+	return b.String()
+}
+
+// writeHTMLSource writes source fragment of frame the same way as sourceRows.
+func writeHTMLSource(b *strings.Builder, f ReportFrame) {
+	if f.Warning != "" {
+		fmt.Fprintf(b, "<div class=\"tracerr-warning\">%s</div>\n", html.EscapeString(f.Warning))
+		return
+	}
+	if len(f.Source) == 0 {
+		return
+	}
+	width := len(strconv.Itoa(f.Source[len(f.Source)-1].Number))
+	b.WriteString(`<pre class="tracerr-source">`)
+	for i, line := range f.Source {
+		class := "tracerr-line"
+		if i == f.Highlight {
+			class += " tracerr-traced"
+		}
+		fmt.Fprintf(
+			b, "<span class=\"%s\"><span class=\"tracerr-num\">%*d</span>%s</span>",
+			class, width, line.Number, html.EscapeString(line.Text),
+		)
+	}
+	b.WriteString("</pre>\n")
+}
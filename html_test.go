@@ -0,0 +1,57 @@
+package tracerr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestSprintHTML(t *testing.T) {
+	frame := tracerr.Frame{Func: "main.<walk>", Line: 9, Path: "error_helper_test.go"}
+	err := tracerr.CustomError(
+		fmt.Errorf("<b>failed</b>: %w", errors.New("cause & effect")),
+		[]tracerr.Frame{
+			frame,
+			frame,
+			{Func: "main.main", Line: 42, Path: "/tmp/not_exists.go"},
+		},
+	)
+	output := tracerr.SprintHTML(err, 2, 1)
+	start := strings.Index(output, "</style>\n")
+	if !strings.HasPrefix(output, `<div class="tracerr">`) || start < 0 {
+		t.Fatalf("tracerr.SprintHTML(err, 2, 1) = %#v", output)
+	}
+	expected := strings.Join([]string{
+		`<div class="tracerr-message">&lt;b&gt;failed&lt;/b&gt;: cause &amp; effect</div>`,
+		`<div class="tracerr-chain">caused by: cause &amp; effect</div>`,
+		`<details class="tracerr-frame" open><summary>error_helper_test.go:9 main.&lt;walk&gt;()</summary>`,
+		`<pre class="tracerr-source">` +
+			`<span class="tracerr-line"><span class="tracerr-num"> 7</span></span>` +
+			`<span class="tracerr-line"><span class="tracerr-num"> 8</span>func addFrameA(message string) error {</span>` +
+			`<span class="tracerr-line tracerr-traced"><span class="tracerr-num"> 9</span>	return addFrameB(message)</span>` +
+			`<span class="tracerr-line"><span class="tracerr-num">10</span>}</span></pre>`,
+		`</details>`,
+		`<div class="tracerr-frame">error_helper_test.go:9 main.&lt;walk&gt;()</div>`,
+		`<details class="tracerr-frame" open><summary>/tmp/not_exists.go:42 main.main()</summary>`,
+		`<div class="tracerr-warning">tracerr: file /tmp/not_exists.go not found</div>`,
+		`</details>`,
+		`</div>`,
+	}, "\n")
+	if output[start+len("</style>\n"):] != expected {
+		t.Errorf("tracerr.SprintHTML(err, 2, 1) = %s; want %s", output[start+len("</style>\n"):], expected)
+	}
+
+	output = tracerr.SprintHTML(err, 0)
+	if strings.Contains(output, "<details") || !strings.Contains(output, `<div class="tracerr-frame">/tmp/not_exists.go:42 main.main()</div>`) {
+		t.Errorf("tracerr.SprintHTML(err, 0) = %s; want frames without source", output)
+	}
+	if output := tracerr.SprintHTML(errors.New("<regular>")); !strings.Contains(output, `<div class="tracerr-message">&lt;regular&gt;</div>`) {
+		t.Errorf("tracerr.SprintHTML(regular error) = %s", output)
+	}
+	if output := tracerr.SprintHTML(nil); output != "" {
+		t.Errorf("tracerr.SprintHTML(nil) = %#v; want %#v", output, "")
+	}
+}
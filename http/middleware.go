@@ -0,0 +1,148 @@
+// Package tracerrhttp provides net/http middleware, which recovers panics
+// and handles errors returned by handlers with stack traces.
+package tracerrhttp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/ztrue/tracerr"
+)
+
+// HandlerFunc is an HTTP handler that could return an error.
+//
+// It could be used as http.Handler directly, in this case errors are handled by Default.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls f and handles returned error with Default middleware.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Default.Func(f).ServeHTTP(w, r)
+}
+
+// Default is a middleware used by HandlerFunc.ServeHTTP.
+var Default = &Middleware{}
+
+// Middleware recovers panics of handlers and handles returned errors.
+//
+// Each error is logged and responded with status 500,
+// unless handler has already written a response.
+type Middleware struct {
+	// Development enables responses with a page that contains
+	// error message, chain of wrapped errors and stack trace with source fragments.
+	// Otherwise response contains only a generic message with error ID.
+	Development bool
+	// Log is called for every error with its ID.
+	// By default, error ID and output of tracerr.PrintSource are printed.
+	Log func(id string, err error)
+}
+
+// Wrap returns a handler that calls next and recovers its panics.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer m.recover(rw, r)
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Func returns a handler that calls fn, handles returned error
+// and recovers its panics.
+func (m *Middleware) Func(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer m.recover(rw, r)
+		if err := fn(rw, r); err != nil {
+			m.handle(rw, r, tracerr.Wrap(err))
+		}
+	})
+}
+
+// recover handles panic, if any.
+func (m *Middleware) recover(w *responseWriter, r *http.Request) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	// It's used by net/http to abort a response silently.
+	if p == http.ErrAbortHandler {
+		panic(p)
+	}
+	m.handle(w, r, tracerr.Recovered(p))
+}
+
+func (m *Middleware) handle(w *responseWriter, r *http.Request, err tracerr.Error) {
+	id := newID()
+	if m.Log != nil {
+		m.Log(id, err)
+	} else {
+		fmt.Printf("error %s: %s\n", id, tracerr.SprintSource(err))
+	}
+	if w.written {
+		return
+	}
+	w.Header().Set("X-Error-Id", id)
+	if !m.Development {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Internal Server Error\nError ID: %s\n", id)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	writePage(w, r, id, err)
+}
+
+// newID returns a random error ID.
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// responseWriter tracks whether response is already written.
+type responseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client, if the original writer supports it.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.written = true
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection, if the original writer supports it.
+// Errors are not responded after that.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.written = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the original writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
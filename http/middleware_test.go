@@ -0,0 +1,160 @@
+package tracerrhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+	tracerrhttp "github.com/ztrue/tracerr/http"
+)
+
+type logged struct {
+	id  string
+	err error
+}
+
+func newMiddleware(development bool, logs *[]logged) *tracerrhttp.Middleware {
+	return &tracerrhttp.Middleware{
+		Development: development,
+		Log: func(id string, err error) {
+			*logs = append(*logs, logged{id, err})
+		},
+	}
+}
+
+func TestFunc(t *testing.T) {
+	var logs []logged
+	m := newMiddleware(false, &logs)
+	handler := m.Func(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("db is down")
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+
+	if len(logs) != 1 {
+		t.Fatalf("len(logs) = %#v; want %#v", len(logs), 1)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("w.Code = %#v; want %#v", w.Code, http.StatusInternalServerError)
+	}
+	if w.Header().Get("X-Error-Id") != logs[0].id {
+		t.Errorf("X-Error-Id = %#v; want %#v", w.Header().Get("X-Error-Id"), logs[0].id)
+	}
+	expected := "Internal Server Error\nError ID: " + logs[0].id + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("w.Body = %#v; want %#v", w.Body.String(), expected)
+	}
+	err, ok := logs[0].err.(tracerr.Error)
+	if !ok || err.Error() != "db is down" || len(err.StackTrace()) == 0 {
+		t.Errorf("logs[0].err = %#v; want error with stack trace", logs[0].err)
+	}
+}
+
+func TestWrapPanic(t *testing.T) {
+	var logs []logged
+	m := newMiddleware(true, &logs)
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []string
+		_ = users[len(r.URL.Path)]
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/users?id=<1>", nil))
+
+	if len(logs) != 1 {
+		t.Fatalf("len(logs) = %#v; want %#v", len(logs), 1)
+	}
+	err := logs[0].err.(tracerr.Error)
+	if !strings.HasPrefix(err.Error(), "runtime error: index out of range") {
+		t.Errorf("err.Error() = %#v", err.Error())
+	}
+	frame := err.StackTrace()[0]
+	if !strings.HasSuffix(frame.Func, "TestWrapPanic.func1") {
+		t.Errorf("err.StackTrace()[0] = %#v; want panicking function", frame)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("w.Code = %#v; want %#v", w.Code, http.StatusInternalServerError)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Content-Type = %#v; want text/html", w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		`<div class="tracerr-message">runtime error: index out of range`,
+		"GET /users?id=&lt;1&gt;, error ID " + logs[0].id,
+		frame.Func + "()",
+		`<span class="tracerr-line tracerr-traced"><span class="tracerr-num">`,
+		"_ = users[len(r.URL.Path)]",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("w.Body = %s; want to contain %#v", body, expected)
+		}
+	}
+}
+
+func TestWrapWritten(t *testing.T) {
+	var logs []logged
+	m := newMiddleware(true, &logs)
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("stop")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if len(logs) != 1 || logs[0].err.Error() != "stop" {
+		t.Fatalf("logs = %#v; want one error", logs)
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("response = %#v %#v; want untouched", w.Code, w.Body.String())
+	}
+}
+
+func TestWrapAbort(t *testing.T) {
+	var logs []logged
+	m := newMiddleware(false, &logs)
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recover() = %#v; want %#v", p, http.ErrAbortHandler)
+		}
+		if len(logs) != 0 {
+			t.Errorf("len(logs) = %#v; want %#v", len(logs), 0)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestWrapOptionalInterfaces(t *testing.T) {
+	var logs []logged
+	m := newMiddleware(false, &logs)
+	handler := m.Func(func(w http.ResponseWriter, r *http.Request) error {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("w is not http.Flusher")
+		}
+		w.Write([]byte("event"))
+		flusher.Flush()
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatal("w is not http.Hijacker")
+		}
+		if _, _, err := hijacker.Hijack(); err != http.ErrNotSupported {
+			t.Errorf("Hijack() error = %#v; want %#v", err, http.ErrNotSupported)
+		}
+		return errors.New("stream is closed")
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+
+	if !w.Flushed {
+		t.Error("w.Flushed = false; want true")
+	}
+	if len(logs) != 1 || w.Body.String() != "event" {
+		t.Errorf("response = %#v; want untouched", w.Body.String())
+	}
+}
@@ -0,0 +1,38 @@
+package tracerrhttp
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+
+	"github.com/ztrue/tracerr"
+)
+
+const pageTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { margin: 2em; }
+.request { font-family: sans-serif; color: #777; }
+</style>
+</head>
+<body>
+<p class="request">%s %s, error ID %s</p>
+%s
+</body>
+</html>
+`
+
+// writePage writes HTML page with error details.
+func writePage(w io.Writer, r *http.Request, id string, err error) error {
+	_, writeErr := fmt.Fprintf(
+		w, pageTemplate,
+		html.EscapeString(err.Error()),
+		html.EscapeString(r.Method), html.EscapeString(r.URL.String()), id,
+		tracerr.SprintHTML(err),
+	)
+	return writeErr
+}
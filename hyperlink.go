@@ -0,0 +1,77 @@
+package tracerr
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// URL templates to open frames in editors, see HyperlinkTemplate.
+const (
+	FileHyperlink   = "file://{path}"
+	VSCodeHyperlink = "vscode://file{path}:{line}"
+	IdeaHyperlink   = "idea://open?file={path}&line={line}"
+	GoLandHyperlink = "goland://open?file={path}&line={line}"
+)
+
+// HyperlinkTemplate enables terminal hyperlinks (OSC 8) on frames
+// in colorized output, so a click on a frame opens it in an editor.
+// It's a URL template with placeholders:
+// {path} is an escaped absolute path starting with slash, {line} is a line number
+// and {func} is a function of frame.
+//
+// Hyperlinks are disabled if it's empty,
+// or terminal doesn't support them, see HyperlinksSupported.
+var HyperlinkTemplate = ""
+
+// HyperlinksSupported defines whether terminal supports hyperlinks.
+// By default, it's detected by environment variables of known terminals,
+// FORCE_HYPERLINK=1 or FORCE_HYPERLINK=0 could be used to override it.
+var HyperlinksSupported = detectHyperlinks(os.Getenv)
+
+// detectHyperlinks returns true if terminal supports hyperlinks.
+func detectHyperlinks(getenv func(key string) string) bool {
+	if force := getenv("FORCE_HYPERLINK"); force != "" {
+		return force != "0"
+	}
+	if getenv("CI") != "" || getenv("TERM") == "dumb" {
+		return false
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	if vte, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true
+	}
+	for _, key := range []string{"WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "ALACRITTY_WINDOW_ID", "DOMTERM"} {
+		if getenv(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hyperlink wraps text of frame in OSC 8 hyperlink, if it's enabled.
+func hyperlink(frame Frame, text string) string {
+	if HyperlinkTemplate == "" || !HyperlinksSupported {
+		return text
+	}
+	path := frame.Path
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	// Windows paths, such as C:/file.go, need a leading slash in URL.
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	link := strings.NewReplacer(
+		"{path}", (&url.URL{Path: path}).EscapedPath(),
+		"{line}", strconv.Itoa(frame.Line),
+		"{func}", url.QueryEscape(frame.Func),
+	).Replace(HyperlinkTemplate)
+	return "\x1b]8;;" + link + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
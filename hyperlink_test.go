@@ -0,0 +1,80 @@
+package tracerr_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestHyperlinks(t *testing.T) {
+	err := tracerr.CustomError(errors.New("some error"), []tracerr.Frame{
+		{Func: "main.walk", Line: 9, Path: "error_helper_test.go"},
+		{Func: "main.(*T).Run", Line: 42, Path: "/tmp/dir with space/not_exists.go"},
+	})
+	supported := tracerr.HyperlinksSupported
+	defer func() {
+		tracerr.HyperlinkTemplate = ""
+		tracerr.HyperlinksSupported = supported
+	}()
+	tracerr.HyperlinksSupported = true
+	plain := tracerr.SprintSourceColor(err)
+	if strings.Contains(plain, "\x1b]8;;") {
+		t.Errorf("tracerr.SprintSourceColor(err) = %#v; want no hyperlinks by default", plain)
+	}
+
+	wd, _ := os.Getwd()
+	cases := []struct {
+		Template string
+		Expected []string
+	}{
+		{
+			Template: tracerr.VSCodeHyperlink,
+			Expected: []string{
+				"vscode://file" + filepath.ToSlash(filepath.Join(wd, "error_helper_test.go")) + ":9",
+				"vscode://file/tmp/dir%20with%20space/not_exists.go:42",
+			},
+		},
+		{
+			Template: tracerr.GoLandHyperlink,
+			Expected: []string{
+				"goland://open?file=" + filepath.ToSlash(filepath.Join(wd, "error_helper_test.go")) + "&line=9",
+				"goland://open?file=/tmp/dir%20with%20space/not_exists.go&line=42",
+			},
+		},
+		{
+			Template: "https://example.com/?func={func}",
+			Expected: []string{
+				"https://example.com/?func=main.walk",
+				"https://example.com/?func=main.%28%2AT%29.Run",
+			},
+		},
+	}
+	for _, c := range cases {
+		tracerr.HyperlinkTemplate = c.Template
+		rows := strings.Split(tracerr.SprintSourceColor(err, 0), "\n")
+		expected := []string{
+			"some error",
+			"\x1b]8;;" + c.Expected[0] + "\x1b\\" + bold("error_helper_test.go:9 main.walk()") + "\x1b]8;;\x1b\\",
+			"\x1b]8;;" + c.Expected[1] + "\x1b\\" + bold("/tmp/dir with space/not_exists.go:42 main.(*T).Run()") + "\x1b]8;;\x1b\\",
+		}
+		if strings.Join(rows, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("tracerr.SprintSourceColor(err, 0) with %#v = %#v; want %#v", c.Template, rows, expected)
+		}
+		if output := tracerr.Sprint(err); strings.Contains(output, "\x1b]8;;") {
+			t.Errorf("tracerr.Sprint(err) = %#v; want no hyperlinks without color", output)
+		}
+		parsed, parseErr := tracerr.Parse(tracerr.SprintSourceColor(err))
+		if parseErr != nil || len(parsed.StackTrace()) != 2 || parsed.StackTrace()[1].Path != "/tmp/dir with space/not_exists.go" {
+			t.Errorf("tracerr.Parse(tracerr.SprintSourceColor(err)) = %#v, %#v", parsed, parseErr)
+		}
+	}
+
+	tracerr.HyperlinksSupported = false
+	if output := tracerr.SprintSourceColor(err); output != plain {
+		t.Errorf("tracerr.SprintSourceColor(err) = %#v; want %#v if hyperlinks are not supported", output, plain)
+	}
+}
@@ -0,0 +1,213 @@
+package tracerr
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// errInvalidDropped is returned by UnmarshalJSON for invalid numbers of dropped frames.
+var errInvalidDropped = errors.New("tracerr: invalid dropped frames")
+
+// jsonError is a serialized form of an error.
+type jsonError struct {
+	// Message contains error message.
+	Message string `json:"message"`
+	// Chain contains messages of errors wrapped by the original error.
+	Chain []string `json:"chain,omitempty"`
+	// Frames contains stack trace.
+	Frames []jsonFrame `json:"frames"`
+	// Dropped is a number of frames dropped from the middle of the stack.
+	Dropped int `json:"dropped,omitempty"`
+	// DroppedAt is an index of the frame that follows dropped frames.
+	DroppedAt int `json:"dropped_at,omitempty"`
+	// Trail contains call sites, where error was wrapped on its way up.
+	Trail []jsonTrailPoint `json:"trail,omitempty"`
+	// StartedBy contains stack traces of goroutine starts, the nearest one first.
+	StartedBy [][]jsonFrame `json:"started_by,omitempty"`
+}
+
+type jsonFrame struct {
+	Func string `json:"func"`
+	Line int    `json:"line"`
+	Path string `json:"path"`
+	// Source contains source fragment, if requested.
+	Source []jsonLine `json:"source,omitempty"`
+	// Warning contains a reason why source fragment is missing.
+	Warning string `json:"warning,omitempty"`
+}
+
+type jsonTrailPoint struct {
+	jsonFrame
+	Note string `json:"note,omitempty"`
+}
+
+func newJSONFrame(f Frame) jsonFrame {
+	return jsonFrame{
+		Func: f.Func,
+		Line: f.Line,
+		Path: f.Path,
+	}
+}
+
+func (f jsonFrame) frame() Frame {
+	return Frame{
+		Func: f.Func,
+		Line: f.Line,
+		Path: f.Path,
+	}
+}
+
+// framesFromJSON returns frames without source fragments.
+func framesFromJSON(jfs []jsonFrame) []Frame {
+	frames := make([]Frame, len(jfs))
+	for i, f := range jfs {
+		frames[i] = f.frame()
+	}
+	return frames
+}
+
+type jsonLine struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Traced bool   `json:"traced,omitempty"`
+}
+
+// chainError restores a chain of wrapped errors from JSON.
+type chainError struct {
+	message string
+	next    error
+}
+
+func (e *chainError) Error() string {
+	return e.message
+}
+
+func (e *chainError) Unwrap() error {
+	return e.next
+}
+
+// MarshalJSON returns JSON with error message, chain of wrapped errors and stack trace.
+func (e *errorData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSON(e, nil))
+}
+
+// MarshalJSON returns error encoded to JSON, which contains error message,
+// messages of wrapped errors and stack trace if any.
+//
+// It could be decoded back with UnmarshalJSON.
+func MarshalJSON(err error) ([]byte, error) {
+	return json.Marshal(toJSON(err, nil))
+}
+
+// MarshalJSONSource returns error encoded to JSON the same way as MarshalJSON,
+// but with source fragments for each frame.
+// Number of source lines is defined by the same rules as in PrintSource.
+func MarshalJSONSource(err error, nums ...int) ([]byte, error) {
+	if nums == nil {
+		nums = []int{}
+	}
+	return json.Marshal(toJSON(err, nums))
+}
+
+// UnmarshalJSON decodes an error encoded by MarshalJSON or MarshalJSONSource.
+// The result has the same message, chain of wrapped errors and stack trace,
+// so it could be printed the same way as the original error.
+func UnmarshalJSON(data []byte) (Error, error) {
+	var je *jsonError
+	if err := json.Unmarshal(data, &je); err != nil {
+		return nil, err
+	}
+	if je == nil {
+		return nil, nil
+	}
+	var err error
+	for i := len(je.Chain) - 1; i >= 0; i-- {
+		err = &chainError{message: je.Chain[i], next: err}
+	}
+	err = &chainError{message: je.Message, next: err}
+	frames := framesFromJSON(je.Frames)
+	if je.Dropped < 0 || je.DroppedAt < 0 || je.DroppedAt > len(frames) {
+		return nil, errInvalidDropped
+	}
+	e := CustomError(err, frames).(*errorData)
+	if je.Dropped > 0 {
+		e.dropped = je.Dropped
+		e.gap = je.DroppedAt
+	}
+	for _, p := range je.Trail {
+		e.trail = append(e.trail, trailPoint{frame: p.frame(), note: p.Note})
+	}
+	var stacks [][]Frame
+	for _, stack := range je.StartedBy {
+		stacks = append(stacks, framesFromJSON(stack))
+	}
+	e.spawn = spawnChain(stacks)
+	return e, nil
+}
+
+// toJSON converts error to its serialized form.
+// Source fragments are added only if nums is not nil.
+func toJSON(err error, nums []int) *jsonError {
+	if err == nil {
+		return nil
+	}
+	je := &jsonError{
+		Message: err.Error(),
+		Frames:  []jsonFrame{},
+	}
+	je.Chain = chain(err)
+	e, ok := err.(Error)
+	if !ok {
+		return je
+	}
+	before, after, withSource := calcRows(nums)
+	withSource = withSource && nums != nil
+	for _, frame := range e.StackTrace() {
+		jf := newJSONFrame(frame)
+		if withSource {
+			fragment, err := sourceFragment(frame, before, after)
+			if err != nil {
+				jf.Warning = err.Error()
+			}
+			for _, line := range fragment {
+				jf.Source = append(jf.Source, jsonLine{
+					Line:   line.Number,
+					Text:   line.Text,
+					Traced: line.Number == frame.Line,
+				})
+			}
+		}
+		je.Frames = append(je.Frames, jf)
+	}
+	if d, ok := e.(*errorData); ok {
+		je.DroppedAt, je.Dropped = d.droppedFrames()
+		for _, p := range Trail(d) {
+			je.Trail = append(je.Trail, jsonTrailPoint{jsonFrame: newJSONFrame(p.Frame), Note: p.Note})
+		}
+		for _, stack := range StartedBy(d) {
+			jfs := make([]jsonFrame, len(stack))
+			for i, frame := range stack {
+				jfs[i] = newJSONFrame(frame)
+			}
+			je.StartedBy = append(je.StartedBy, jfs)
+		}
+	}
+	return je
+}
+
+// chain returns messages of errors wrapped by err.
+// If err is Error, the error it's created from is skipped,
+// since it has the same message.
+func chain(err error) []string {
+	if e, ok := err.(Error); ok {
+		err = e.Unwrap()
+	}
+	if err == nil {
+		return nil
+	}
+	var messages []string
+	for next := errors.Unwrap(err); next != nil; next = errors.Unwrap(next) {
+		messages = append(messages, next.Error())
+	}
+	return messages
+}
@@ -0,0 +1,166 @@
+package tracerr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestJSON(t *testing.T) {
+	cause := errors.New("file not found")
+	err := tracerr.Wrap(fmt.Errorf("read config: %w", cause))
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatalf("json.Marshal(err) error = %#v", marshalErr)
+	}
+	data2, marshalErr := tracerr.MarshalJSON(err)
+	if marshalErr != nil {
+		t.Fatalf("tracerr.MarshalJSON(err) error = %#v", marshalErr)
+	}
+	if string(data) != string(data2) {
+		t.Errorf("tracerr.MarshalJSON(err) = %s; want %s", data2, data)
+	}
+	if !strings.HasPrefix(string(data), `{"message":"read config: file not found","chain":["file not found"],"frames":[{"func":"github.com/ztrue/tracerr_test.TestJSON","line":15,"path":"`) {
+		t.Errorf("json.Marshal(err) = %s", data)
+	}
+
+	decoded, unmarshalErr := tracerr.UnmarshalJSON(data)
+	if unmarshalErr != nil {
+		t.Fatalf("tracerr.UnmarshalJSON(data) error = %#v", unmarshalErr)
+	}
+	if decoded.Error() != err.Error() {
+		t.Errorf("decoded.Error() = %#v; want %#v", decoded.Error(), err.Error())
+	}
+	unwrapped := errors.Unwrap(decoded.Unwrap())
+	if unwrapped == nil || unwrapped.Error() != cause.Error() {
+		t.Errorf("errors.Unwrap(decoded.Unwrap()) = %#v; want %#v", unwrapped, cause.Error())
+	}
+	assertFrames(t, decoded.StackTrace(), err.StackTrace())
+	if tracerr.SprintSource(decoded) != tracerr.SprintSource(err) {
+		t.Errorf(
+			"tracerr.SprintSource(decoded) = %#v; want %#v",
+			tracerr.SprintSource(decoded), tracerr.SprintSource(err),
+		)
+	}
+}
+
+func TestJSONSource(t *testing.T) {
+	err := tracerr.CustomError(
+		errors.New("some error"),
+		[]tracerr.Frame{
+			{
+				Func: "main.Foo",
+				Line: 17,
+				Path: "error_helper_test.go",
+			},
+			{
+				Func: "main.Bar",
+				Line: 43,
+				Path: "/tmp/not_exists.go",
+			},
+		},
+	)
+	data, marshalErr := tracerr.MarshalJSONSource(err, 1, 1)
+	if marshalErr != nil {
+		t.Fatalf("tracerr.MarshalJSONSource(err, 1, 1) error = %#v", marshalErr)
+	}
+	expected := `{"message":"some error","frames":[` +
+		`{"func":"main.Foo","line":17,"path":"error_helper_test.go","source":[` +
+		`{"line":16,"text":"func addFrameC(message string) error {"},` +
+		`{"line":17,"text":"\treturn tracerr.New(message)","traced":true},` +
+		`{"line":18,"text":"}"}]},` +
+		`{"func":"main.Bar","line":43,"path":"/tmp/not_exists.go","warning":"tracerr: file /tmp/not_exists.go not found"}]}`
+	if string(data) != expected {
+		t.Errorf("tracerr.MarshalJSONSource(err, 1, 1) = %s; want %s", data, expected)
+	}
+	decoded, unmarshalErr := tracerr.UnmarshalJSON(data)
+	if unmarshalErr != nil {
+		t.Fatalf("tracerr.UnmarshalJSON(data) error = %#v", unmarshalErr)
+	}
+	assertFrames(t, decoded.StackTrace(), err.StackTrace())
+}
+
+func TestJSONDropped(t *testing.T) {
+	var recurse func(n int) error
+	recurse = func(n int) error {
+		if n == 0 {
+			return tracerr.New("deep error")
+		}
+		return recurse(n - 1)
+	}
+	defer func(maxFrames int) {
+		tracerr.MaxFrames = maxFrames
+	}(tracerr.MaxFrames)
+	tracerr.MaxFrames = 20
+	err := recurse(100)
+	data, marshalErr := tracerr.MarshalJSON(err)
+	if marshalErr != nil {
+		t.Fatalf("tracerr.MarshalJSON(err) error = %#v", marshalErr)
+	}
+	decoded, unmarshalErr := tracerr.UnmarshalJSON(data)
+	if unmarshalErr != nil {
+		t.Fatalf("tracerr.UnmarshalJSON(data) error = %#v", unmarshalErr)
+	}
+	if tracerr.DroppedFrames(decoded) != tracerr.DroppedFrames(err) {
+		t.Errorf(
+			"tracerr.DroppedFrames(decoded) = %#v; want %#v",
+			tracerr.DroppedFrames(decoded), tracerr.DroppedFrames(err),
+		)
+	}
+	if tracerr.Sprint(decoded) != tracerr.Sprint(err) {
+		t.Errorf(
+			"tracerr.Sprint(decoded) = %#v; want %#v",
+			tracerr.Sprint(decoded), tracerr.Sprint(err),
+		)
+	}
+}
+
+func TestJSONNotInstance(t *testing.T) {
+	data, err := tracerr.MarshalJSON(errors.New("regular error"))
+	if err != nil {
+		t.Fatalf("tracerr.MarshalJSON() error = %#v", err)
+	}
+	expected := `{"message":"regular error","frames":[]}`
+	if string(data) != expected {
+		t.Errorf("tracerr.MarshalJSON() = %s; want %s", data, expected)
+	}
+
+	data, err = tracerr.MarshalJSON(nil)
+	if err != nil || string(data) != "null" {
+		t.Errorf("tracerr.MarshalJSON(nil) = %s, %#v; want null, nil", data, err)
+	}
+	decoded, err := tracerr.UnmarshalJSON(data)
+	if decoded != nil || err != nil {
+		t.Errorf("tracerr.UnmarshalJSON(null) = %#v, %#v; want nil, nil", decoded, err)
+	}
+	if _, err := tracerr.UnmarshalJSON([]byte("{")); err == nil {
+		t.Errorf("tracerr.UnmarshalJSON({) error = nil; want error")
+	}
+}
+
+func TestJSONInvalidDropped(t *testing.T) {
+	for _, dropped := range []string{
+		`"dropped":3,"dropped_at":-1`,
+		`"dropped":3,"dropped_at":2`,
+		`"dropped":-3,"dropped_at":1`,
+	} {
+		data := `{"message":"m","frames":[{"func":"a","line":1,"path":"x.go"}],` + dropped + `}`
+		if decoded, err := tracerr.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("tracerr.UnmarshalJSON(%s) = %#v; want error", data, decoded)
+		}
+	}
+}
+
+func TestFrameJSON(t *testing.T) {
+	// Frames are encoded by users with field names as is.
+	data, err := json.Marshal(tracerr.Frame{Func: "main.main", Line: 1, Path: "main.go"})
+	expected := `{"Func":"main.main","Line":1,"Path":"main.go"}`
+	if err != nil || string(data) != expected {
+		t.Errorf("json.Marshal(frame) = %s, %#v; want %s", data, err, expected)
+	}
+}
@@ -0,0 +1,148 @@
+package tracerr
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Templates of URLs for source code hosting, see Linker.
+const (
+	GitHubTemplate    = "https://{repo}/blob/{rev}/{relpath}#L{line}"
+	GitLabTemplate    = "https://{repo}/-/blob/{rev}/{relpath}#L{line}"
+	GiteaTemplate     = "https://{repo}/src/commit/{rev}/{relpath}#L{line}"
+	BitbucketTemplate = "https://{repo}/src/{rev}/{relpath}#lines-{line}"
+)
+
+// majorVersionPattern matches major version suffix of module path.
+var majorVersionPattern = regexp.MustCompile(`/v\d+$`)
+
+// Linker converts frames of the main module into URLs of source code
+// at the exact revision the program is built from.
+//
+// It could be used as FrameURL, so printers output links to frames:
+//
+//	linker, err := tracerr.NewLinker(tracerr.GitHubTemplate)
+//	if err == nil {
+//		tracerr.FrameURL = linker.URL
+//	}
+type Linker struct {
+	// Template of URL with placeholders:
+	// {module} is a module path, such as "github.com/user/repo/v2",
+	// {repo} is a module path without major version suffix,
+	// {rev} is a revision, {relpath} is a path of file relative to module root,
+	// {line} is a line number, {path} and {func} are path and function of frame.
+	Template string
+	// Module is a path of the main module.
+	Module string
+	// Main is a path of the main package,
+	// which is used to resolve frames of functions in package main.
+	Main string
+	// Revision is a VCS revision, such as commit hash.
+	Revision string
+	// Root is a directory of module on the build machine.
+	// It's optional, since relative paths are also resolved by package of function.
+	Root string
+}
+
+// NewLinker creates a linker for the main module using build info,
+// which contains module path and VCS revision.
+//
+// If template is empty, it's chosen by module host:
+// GitHub, GitLab, Bitbucket and Codeberg are supported.
+func NewLinker(template string) (*Linker, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, errors.New("tracerr: no build info")
+	}
+	l := &Linker{
+		Template: template,
+		Module:   info.Main.Path,
+		Main:     info.Path,
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			l.Revision = setting.Value
+		}
+	}
+	if l.Revision == "" {
+		return nil, errors.New("tracerr: no VCS revision in build info")
+	}
+	if l.Template == "" {
+		switch strings.SplitN(l.Module, "/", 2)[0] {
+		case "github.com":
+			l.Template = GitHubTemplate
+		case "gitlab.com":
+			l.Template = GitLabTemplate
+		case "bitbucket.org":
+			l.Template = BitbucketTemplate
+		case "codeberg.org":
+			l.Template = GiteaTemplate
+		default:
+			return nil, errors.New("tracerr: unknown host of module " + l.Module)
+		}
+	}
+	return l, nil
+}
+
+// URL returns a link to source code of frame.
+// Empty string is returned if frame is out of the main module.
+func (l *Linker) URL(frame Frame) string {
+	relpath := l.relPath(frame)
+	if relpath == "" {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{module}", l.Module,
+		"{repo}", majorVersionPattern.ReplaceAllString(l.Module, ""),
+		"{rev}", l.Revision,
+		"{relpath}", relpath,
+		"{line}", strconv.Itoa(frame.Line),
+		"{path}", frame.Path,
+		"{func}", frame.Func,
+	).Replace(l.Template)
+}
+
+// relPath returns a path of frame relative to module root.
+func (l *Linker) relPath(frame Frame) string {
+	p := filepath.ToSlash(frame.Path)
+	if l.Root != "" {
+		root := strings.TrimSuffix(filepath.ToSlash(l.Root), "/")
+		if strings.HasPrefix(p, root+"/") {
+			return p[len(root)+1:]
+		}
+	}
+	if l.Module == "" {
+		return ""
+	}
+	// Paths start with module path if program is built with -trimpath.
+	if strings.HasPrefix(p, l.Module+"/") {
+		return p[len(l.Module)+1:]
+	}
+	pkg := funcPackage(frame.Func)
+	if pkg == "main" {
+		pkg = l.Main
+	}
+	if pkg == l.Module {
+		return path.Base(p)
+	}
+	if strings.HasPrefix(pkg, l.Module+"/") {
+		return pkg[len(l.Module)+1:] + "/" + path.Base(p)
+	}
+	return ""
+}
+
+// funcPackage returns a package path of function name,
+// such as "github.com/user/repo/pkg" for "github.com/user/repo/pkg.(*T).Method".
+func funcPackage(name string) string {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return name
+	}
+	return name[:slash+1+dot]
+}
@@ -0,0 +1,100 @@
+package tracerr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestLinker(t *testing.T) {
+	linker := &tracerr.Linker{
+		Template: tracerr.GitHubTemplate,
+		Module:   "github.com/user/repo/v2",
+		Main:     "github.com/user/repo/v2/cmd/app",
+		Revision: "abc123",
+	}
+	cases := []struct {
+		Frame    tracerr.Frame
+		Expected string
+	}{
+		{
+			Frame:    tracerr.Frame{Func: "github.com/user/repo/v2/pkg.(*T).Run.func1", Line: 10, Path: "/home/ci/build/pkg/t.go"},
+			Expected: "https://github.com/user/repo/blob/abc123/pkg/t.go#L10",
+		},
+		{
+			Frame:    tracerr.Frame{Func: "github.com/user/repo/v2.New", Line: 5, Path: "/home/ci/build/repo.go"},
+			Expected: "https://github.com/user/repo/blob/abc123/repo.go#L5",
+		},
+		{
+			Frame:    tracerr.Frame{Func: "main.main", Line: 7, Path: "/home/ci/build/cmd/app/main.go"},
+			Expected: "https://github.com/user/repo/blob/abc123/cmd/app/main.go#L7",
+		},
+		{
+			Frame:    tracerr.Frame{Func: "github.com/user/repo/v2/internal.F", Line: 3, Path: "github.com/user/repo/v2/internal/x.go"},
+			Expected: "https://github.com/user/repo/blob/abc123/internal/x.go#L3",
+		},
+		{
+			Frame:    tracerr.Frame{Func: "fmt.Println", Line: 300, Path: "/usr/local/go/src/fmt/print.go"},
+			Expected: "",
+		},
+		{
+			Frame:    tracerr.Frame{Func: "github.com/user/repository.F", Line: 1, Path: "/go/pkg/mod/github.com/user/repository/f.go"},
+			Expected: "",
+		},
+	}
+	for i, c := range cases {
+		if url := linker.URL(c.Frame); url != c.Expected {
+			t.Errorf("linker.URL(cases[%#v].Frame) = %#v; want %#v", i, url, c.Expected)
+		}
+	}
+
+	custom := &tracerr.Linker{
+		Template: "https://git.example.com/{module}/tree/{rev}/{relpath}?line={line}",
+		Module:   "example.com/app",
+		Revision: "abc123",
+		Root:     "/src/app/",
+	}
+	url := custom.URL(tracerr.Frame{Func: "other.F", Line: 4, Path: "/src/app/sub/f.go"})
+	if url != "https://git.example.com/example.com/app/tree/abc123/sub/f.go?line=4" {
+		t.Errorf("custom.URL(frame) = %#v", url)
+	}
+}
+
+func TestFrameURL(t *testing.T) {
+	tracerr.FrameURL = func(frame tracerr.Frame) string {
+		if frame.Func == "main.main" {
+			return ""
+		}
+		return "https://example.com/" + frame.Path + "?a=1&b=2"
+	}
+	defer func() {
+		tracerr.FrameURL = nil
+	}()
+	err := tracerr.CustomError(errors.New("some error"), []tracerr.Frame{
+		{Func: "main.walk", Line: 9, Path: "error_helper_test.go"},
+		{Func: "main.main", Line: 42, Path: "/tmp/not_exists.go"},
+	})
+	expected := strings.Join([]string{
+		"some error",
+		"error_helper_test.go:9 main.walk()",
+		"\thttps://example.com/error_helper_test.go?a=1&b=2",
+		"/tmp/not_exists.go:42 main.main()",
+	}, "\n")
+	if output := tracerr.Sprint(err); output != expected {
+		t.Errorf("tracerr.Sprint(err) = %#v; want %#v", output, expected)
+	}
+	rows := strings.Split(tracerr.SprintSourceColor(err, 1), "\n")
+	if rows[3] != "\t"+black("https://example.com/error_helper_test.go?a=1&b=2") || rows[4] != red("9\t\treturn addFrameB(message)") {
+		t.Errorf("tracerr.SprintSourceColor(err, 1) = %#v", rows)
+	}
+	link := `<a href="https://example.com/error_helper_test.go?a=1&amp;b=2">error_helper_test.go:9 main.walk()</a>`
+	if output := tracerr.SprintHTML(err); !strings.Contains(output, "<summary>"+link+"</summary>") {
+		t.Errorf("tracerr.SprintHTML(err) = %s; want to contain %s", output, link)
+	}
+	parsed, parseErr := tracerr.Parse(tracerr.Sprint(err))
+	if parseErr != nil || len(parsed.StackTrace()) != 2 {
+		t.Errorf("tracerr.Parse(tracerr.Sprint(err)) = %#v, %#v", parsed, parseErr)
+	}
+}
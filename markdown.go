@@ -0,0 +1,97 @@
+package tracerr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`, `~`, `\~`,
+)
+
+// SprintMarkdown returns error output in Markdown, which could be pasted
+// into issues or chats: a heading with message, followed by a list of frames
+// with source fragments in fenced code blocks, where traced line is marked with ">".
+// Number of source lines is defined by the same rules as in PrintSource,
+// pass 0 to output frames without source.
+// See also FrameURL.
+func SprintMarkdown(err error, nums ...int) string {
+	return RenderMarkdown(BuildReport(err, NewReportOptions(nums...)))
+}
+
+// RenderMarkdown returns report in the format of SprintMarkdown.
+func RenderMarkdown(r *Report) string {
+	if r == nil {
+		return ""
+	}
+	lines := strings.Split(r.Message, "\n")
+	rows := []string{"### " + markdownEscaper.Replace(lines[0])}
+	if len(lines) > 1 {
+		rows = append(rows, "")
+		for _, line := range lines[1:] {
+			// Two trailing spaces keep line breaks.
+			rows = append(rows, markdownEscaper.Replace(line)+"  ")
+		}
+	}
+	for _, message := range r.Chain {
+		rows = append(rows, "", "Caused by: "+markdownEscaper.Replace(message))
+	}
+	if !r.Traced {
+		return strings.Join(rows, "\n")
+	}
+	rows = append(rows, "")
+	for _, f := range r.Frames {
+		if f.Note != "" {
+			rows = append(rows, "- _"+markdownEscaper.Replace(f.Note)+"_")
+			continue
+		}
+		location := "`" + f.Path + ":" + strconv.Itoa(f.Line) + "`"
+		if f.URL != "" {
+			location = "[" + location + "](" + f.URL + ")"
+		}
+		rows = append(rows, "- "+location+" `"+f.Func+"()`")
+		if r.WithSource && !f.Repeated {
+			rows = markdownSource(rows, f)
+		}
+	}
+	if len(r.Trail) > 0 {
+		rows = append(rows, "", "Returned through:", "")
+		for _, p := range r.Trail {
+			row := "- `" + p.Path + ":" + strconv.Itoa(p.Line) + "` `" + p.Func + "()`"
+			if p.Note != "" {
+				row += " " + markdownEscaper.Replace(p.Note)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return strings.TrimRight(strings.Join(rows, "\n"), "\n")
+}
+
+// markdownSource adds source fragment of frame as a fenced code block
+// nested in a list item.
+func markdownSource(rows []string, f ReportFrame) []string {
+	if f.Warning != "" {
+		return append(rows, "", "  _"+markdownEscaper.Replace(f.Warning)+"_", "")
+	}
+	if len(f.Source) == 0 {
+		return rows
+	}
+	fence := "```"
+	for _, line := range f.Source {
+		for strings.Contains(line.Text, fence) {
+			fence += "`"
+		}
+	}
+	rows = append(rows, "", "  "+fence+"go")
+	width := len(strconv.Itoa(f.Source[len(f.Source)-1].Number))
+	for i, line := range f.Source {
+		marker := " "
+		if i == f.Highlight {
+			marker = ">"
+		}
+		rows = append(rows, fmt.Sprintf("  %s %*d\t%s", marker, width, line.Number, line.Text))
+	}
+	return append(rows, "  "+fence, "")
+}
@@ -0,0 +1,80 @@
+package tracerr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestSprintMarkdown(t *testing.T) {
+	frame := tracerr.Frame{Func: "main.walk", Line: 9, Path: "error_helper_test.go"}
+	err := tracerr.CustomError(
+		fmt.Errorf("*failed*\nat <step> #2: %w", errors.New("cause")),
+		[]tracerr.Frame{
+			frame,
+			frame,
+			frame,
+			{Func: "main.main", Line: 42, Path: "/tmp/not_exists.go"},
+		},
+	)
+	output := tracerr.SprintMarkdown(err, 2, 1)
+	expected := strings.Join([]string{
+		`### \*failed\*`,
+		``,
+		`at \<step\> \#2: cause  `,
+		``,
+		`Caused by: cause`,
+		``,
+		"- `error_helper_test.go:9` `main.walk()`",
+		``,
+		"  ```go",
+		"     7\t",
+		"     8\tfunc addFrameA(message string) error {",
+		"  >  9\t\treturn addFrameB(message)",
+		"    10\t}",
+		"  ```",
+		``,
+		`- _\[previous 1 frame repeated 2 times\]_`,
+		"- `/tmp/not_exists.go:42` `main.main()`",
+		``,
+		`  _tracerr: file /tmp/not\_exists.go not found_`,
+	}, "\n")
+	if output != expected {
+		t.Errorf("tracerr.SprintMarkdown(err, 2, 1) = %#v; want %#v", output, expected)
+	}
+
+	tracerr.FrameURL = func(frame tracerr.Frame) string {
+		if frame.Func == "main.main" {
+			return ""
+		}
+		return fmt.Sprintf("https://example.com/%s#L%d", frame.Path, frame.Line)
+	}
+	defer func() {
+		tracerr.FrameURL = nil
+	}()
+	output = tracerr.SprintMarkdown(err, 0)
+	expected = strings.Join([]string{
+		`### \*failed\*`,
+		``,
+		`at \<step\> \#2: cause  `,
+		``,
+		`Caused by: cause`,
+		``,
+		"- [`error_helper_test.go:9`](https://example.com/error_helper_test.go#L9) `main.walk()`",
+		`- _\[previous 1 frame repeated 2 times\]_`,
+		"- `/tmp/not_exists.go:42` `main.main()`",
+	}, "\n")
+	if output != expected {
+		t.Errorf("tracerr.SprintMarkdown(err, 0) = %#v; want %#v", output, expected)
+	}
+
+	if output := tracerr.SprintMarkdown(errors.New("regular error")); output != "### regular error" {
+		t.Errorf("tracerr.SprintMarkdown(regular error) = %#v; want %#v", output, "### regular error")
+	}
+	if output := tracerr.SprintMarkdown(nil); output != "" {
+		t.Errorf("tracerr.SprintMarkdown(nil) = %#v; want %#v", output, "")
+	}
+}
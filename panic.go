@@ -0,0 +1,163 @@
+package tracerr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Goroutine contains metadata of a goroutine parsed from a goroutine dump.
+// It's an original error of errors returned by ParsePanic.
+type Goroutine struct {
+	// ID is a goroutine ID.
+	ID int
+	// State is a goroutine state, such as "running" or "chan receive, 5 minutes".
+	State string
+	// Panic contains a panic message without "panic: " prefix,
+	// or the whole message of a fatal error.
+	// It's empty if goroutine didn't panic.
+	Panic string
+	// CreatedBy contains a frame where goroutine was created, if known.
+	CreatedBy *Frame
+	// CreatedIn contains ID of the goroutine, which created this one, if known.
+	CreatedIn int
+	// Elided is a number of frames elided by runtime in the middle of the stack.
+	Elided int
+	// Truncated is true if runtime elided frames at the bottom of the stack.
+	Truncated bool
+}
+
+// Error returns panic message, or goroutine ID and state if it didn't panic.
+func (g *Goroutine) Error() string {
+	if g.Panic != "" {
+		return g.Panic
+	}
+	return fmt.Sprintf("goroutine %d [%s]", g.ID, g.State)
+}
+
+var (
+	goroutinePattern = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)? \[(.*)\]:$`)
+	locationPattern  = regexp.MustCompile(`^\t(.+):(\d+)(?: \+0x[0-9a-f]+)?(?: .*)?$`)
+	createdByPattern = regexp.MustCompile(`^created by (.+?)(?: in goroutine (\d+))?$`)
+	elidedPattern    = regexp.MustCompile(`^\.\.\.(\d+) frames elided\.\.\.$`)
+)
+
+// additionalElided is a line of goroutine dump, which replaces frames at the bottom of deep stacks.
+const additionalElided = "...additional frames elided..."
+
+// ParsePanic parses Go panic output, or a goroutine dump
+// such as returned by runtime.Stack or debug.Stack,
+// and returns an error with stack trace for each goroutine.
+//
+// Original errors are of type *Goroutine, which contains goroutine metadata,
+// the panicking goroutine also contains panic message.
+// Lines that are not a part of goroutine dump are ignored.
+func ParsePanic(text string) ([]Error, error) {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	var (
+		errs    []Error
+		message []string
+		// g is the goroutine being parsed, nil if outside of goroutine.
+		g      *Goroutine
+		frames []Frame
+		gap    int
+	)
+	flush := func() {
+		if g == nil {
+			return
+		}
+		e := CustomError(g, frames).(*errorData)
+		if g.Elided > 0 {
+			e.dropped = g.Elided
+			e.gap = gap
+		}
+		errs = append(errs, e)
+		g, frames, gap = nil, nil, 0
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := goroutinePattern.FindStringSubmatch(line); m != nil {
+			flush()
+			id, _ := strconv.Atoi(m[1])
+			g = &Goroutine{
+				ID:    id,
+				State: m[2],
+			}
+			// Panic message belongs to the first goroutine.
+			if message != nil && len(errs) == 0 {
+				g.Panic = strings.TrimSpace(strings.Join(message, "\n"))
+				message = nil
+			}
+			frames = []Frame{}
+			continue
+		}
+		if g == nil {
+			if strings.HasPrefix(line, "panic: ") {
+				message = []string{strings.TrimPrefix(line, "panic: ")}
+			} else if strings.HasPrefix(line, "fatal error: ") {
+				message = []string{line}
+			} else if message != nil && len(errs) == 0 {
+				// Panic message could be multiline, it also includes
+				// nested panics and signal details.
+				message = append(message, line)
+			}
+			continue
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		if m := elidedPattern.FindStringSubmatch(line); m != nil {
+			g.Elided, _ = strconv.Atoi(m[1])
+			gap = len(frames)
+			continue
+		}
+		if line == additionalElided {
+			g.Truncated = true
+			continue
+		}
+		if i+1 >= len(lines) {
+			continue
+		}
+		location := locationPattern.FindStringSubmatch(lines[i+1])
+		if location == nil {
+			continue
+		}
+		i++
+		lineNum, _ := strconv.Atoi(location[2])
+		if m := createdByPattern.FindStringSubmatch(line); m != nil {
+			g.CreatedBy = &Frame{
+				Func: m[1],
+				Line: lineNum,
+				Path: location[1],
+			}
+			g.CreatedIn, _ = strconv.Atoi(m[2])
+			continue
+		}
+		frames = append(frames, Frame{
+			Func: trimArgs(line),
+			Line: lineNum,
+			Path: location[1],
+		})
+	}
+	flush()
+	if len(errs) == 0 {
+		return nil, errors.New("tracerr: no goroutines found")
+	}
+	return errs, nil
+}
+
+// trimArgs removes arguments from a function call in goroutine dump,
+// such as "main.(*T).foo(0x1, {0x2, 0x3})".
+func trimArgs(call string) string {
+	if !strings.HasSuffix(call, ")") {
+		return call
+	}
+	i := strings.LastIndex(call, "(")
+	if i <= 0 {
+		return call
+	}
+	return call[:i]
+}
@@ -0,0 +1,178 @@
+package tracerr_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestParsePanic(t *testing.T) {
+	text := `some log line
+panic: something went wrong [recovered]
+	panic: something else
+
+goroutine 7 [running]:
+main.(*Reader).read(0xc000012345, {0x4a1c20?, 0x55e060?})
+	/src/github.com/john/doe/reader.go:42 +0x1d
+main.main()
+	/src/github.com/john/doe/main.go:12 +0x25
+
+goroutine 18 [chan receive, 5 minutes]:
+main.worker(...)
+	/src/github.com/john/doe/worker.go:7
+...2 frames elided...
+main.loop()
+	/src/github.com/john/doe/worker.go:3 +0x1a fp=0xc000 sp=0xc001 pc=0x4a1c20
+...additional frames elided...
+created by main.main in goroutine 7
+	/src/github.com/john/doe/main.go:10 +0x56
+exit status 2
+`
+	errs, err := tracerr.ParsePanic(text)
+	if err != nil {
+		t.Fatalf("tracerr.ParsePanic(text) error = %#v", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("len(errs) = %#v; want %#v", len(errs), 2)
+	}
+
+	expectedMessage := "something went wrong [recovered]\n\tpanic: something else"
+	if errs[0].Error() != expectedMessage {
+		t.Errorf("errs[0].Error() = %#v; want %#v", errs[0].Error(), expectedMessage)
+	}
+	var g *tracerr.Goroutine
+	if !errors.As(errs[0], &g) {
+		t.Fatalf("errs[0] is not *tracerr.Goroutine")
+	}
+	if g.ID != 7 || g.State != "running" || g.CreatedBy != nil {
+		t.Errorf("errs[0] goroutine = %#v", g)
+	}
+	assertFrames(t, errs[0].StackTrace(), []tracerr.Frame{
+		{
+			Func: "main.(*Reader).read",
+			Line: 42,
+			Path: "/src/github.com/john/doe/reader.go",
+		},
+		{
+			Func: "main.main",
+			Line: 12,
+			Path: "/src/github.com/john/doe/main.go",
+		},
+	})
+
+	if errs[1].Error() != "goroutine 18 [chan receive, 5 minutes]" {
+		t.Errorf("errs[1].Error() = %#v", errs[1].Error())
+	}
+	if !errors.As(errs[1], &g) {
+		t.Fatalf("errs[1] is not *tracerr.Goroutine")
+	}
+	expectedCreatedBy := tracerr.Frame{
+		Func: "main.main",
+		Line: 10,
+		Path: "/src/github.com/john/doe/main.go",
+	}
+	if g.CreatedBy == nil || *g.CreatedBy != expectedCreatedBy {
+		t.Errorf("g.CreatedBy = %#v; want %#v", g.CreatedBy, expectedCreatedBy)
+	}
+	if g.CreatedIn != 7 || g.Elided != 2 || !g.Truncated {
+		t.Errorf("errs[1] goroutine = %#v", g)
+	}
+	assertFrames(t, errs[1].StackTrace(), []tracerr.Frame{
+		{
+			Func: "main.worker",
+			Line: 7,
+			Path: "/src/github.com/john/doe/worker.go",
+		},
+		{
+			Func: "main.loop",
+			Line: 3,
+			Path: "/src/github.com/john/doe/worker.go",
+		},
+	})
+	if tracerr.DroppedFrames(errs[1]) != 2 {
+		t.Errorf("tracerr.DroppedFrames(errs[1]) = %#v; want %#v", tracerr.DroppedFrames(errs[1]), 2)
+	}
+	output := tracerr.Sprint(errs[1])
+	expectedOutput := strings.Join([]string{
+		"goroutine 18 [chan receive, 5 minutes]",
+		"/src/github.com/john/doe/worker.go:7 main.worker()",
+		"[... 2 frames omitted ...]",
+		"/src/github.com/john/doe/worker.go:3 main.loop()",
+	}, "\n")
+	if output != expectedOutput {
+		t.Errorf("tracerr.Sprint(errs[1]) = %#v; want %#v", output, expectedOutput)
+	}
+}
+
+func TestParsePanicStack(t *testing.T) {
+	errs, err := tracerr.ParsePanic(string(debug.Stack()))
+	if err != nil {
+		t.Fatalf("tracerr.ParsePanic(debug.Stack()) error = %#v", err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("len(errs) = %#v; want %#v", len(errs), 1)
+	}
+	frames := errs[0].StackTrace()
+	if len(frames) < 2 || frames[1].Func != "github.com/ztrue/tracerr_test.TestParsePanicStack" {
+		t.Errorf("errs[0].StackTrace() = %#v", frames)
+	}
+
+	buf := make([]byte, 1024*1024)
+	buf = buf[:runtime.Stack(buf, true)]
+	errs, err = tracerr.ParsePanic(string(buf))
+	if err != nil {
+		t.Fatalf("tracerr.ParsePanic(runtime.Stack()) error = %#v", err)
+	}
+	if len(errs) < 2 {
+		t.Errorf("len(errs) = %#v; want >= %#v", len(errs), 2)
+	}
+}
+
+func TestParsePanicProcess(t *testing.T) {
+	if os.Getenv("TRACERR_PANIC") == "1" {
+		addFramePanic()
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestParsePanicProcess$")
+	cmd.Env = append(os.Environ(), "TRACERR_PANIC=1")
+	output, _ := cmd.CombinedOutput()
+	errs, err := tracerr.ParsePanic(string(output))
+	if err != nil {
+		t.Fatalf("tracerr.ParsePanic(output) error = %#v; output = %s", err, output)
+	}
+	// Testing package recovers and panics again, which is reflected in message.
+	if !strings.HasPrefix(errs[0].Error(), "process panic") {
+		t.Errorf("errs[0].Error() = %#v; want prefix %#v", errs[0].Error(), "process panic")
+	}
+	var g *tracerr.Goroutine
+	if !errors.As(errs[0], &g) || g.CreatedBy == nil {
+		t.Errorf("errs[0] goroutine = %#v; want created by", g)
+	}
+	found := false
+	for _, frame := range errs[0].StackTrace() {
+		if frame.Func == "github.com/ztrue/tracerr_test.addFramePanic" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("errs[0].StackTrace() = %#v; want addFramePanic", errs[0].StackTrace())
+	}
+}
+
+func TestParsePanicEmpty(t *testing.T) {
+	errs, err := tracerr.ParsePanic("no goroutines here")
+	if err == nil || errs != nil {
+		t.Errorf("tracerr.ParsePanic() = %#v, %#v; want nil, error", errs, err)
+	}
+}
+
+//go:noinline
+func addFramePanic() {
+	panic("process panic")
+}
@@ -0,0 +1,119 @@
+package tracerr
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// escapePattern matches ANSI color codes and OSC sequences such as hyperlinks.
+	escapePattern    = regexp.MustCompile("\x1b\\[[0-9;]*m|\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\)")
+	framePattern     = regexp.MustCompile(`^(.+):(\d+) (.+)\(\)$`)
+	repeatsPattern   = regexp.MustCompile(`^\[previous (\d+) frames? repeated (\d+) times?\]$`)
+	omittedPattern   = regexp.MustCompile(`^\[\.\.\. (\d+) frames omitted \.\.\.\]$`)
+	sourceRowPattern = regexp.MustCompile(`^ *\d+\t`)
+)
+
+// maxParsedFrames limits a number of frames restored from folded repeats,
+// so a corrupted line doesn't make Parse allocate too much memory.
+const maxParsedFrames = 1 << 16
+
+// Parse parses output of Sprint, SprintSource or SprintSourceColor
+// and returns an error with the same message and stack trace,
+// so it could be printed again in a different way.
+//
+// Colors are ignored, source fragments are skipped
+// and folded repeated frames are restored,
+// up to 65536 frames in total.
+// Frames after "started by:" are restored as goroutine starts, see Go,
+// and frames after "returned through:" are restored as trail, see Wrap.
+// Output must contain at least one frame.
+func Parse(text string) (Error, error) {
+	text = escapePattern.ReplaceAllString(text, "")
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	var (
+		message []string
+		frames  []Frame
+		found   bool
+		dropped int
+		gap     int
+		trail   []TrailPoint
+		inTrail bool
+		// stacks contains own frames and frames of goroutine starts parsed before the current ones.
+		stacks [][]Frame
+	)
+	for _, line := range lines {
+		if m := framePattern.FindStringSubmatch(line); m != nil && !sourceRowPattern.MatchString(line) {
+			lineNum, _ := strconv.Atoi(m[2])
+			frame := Frame{
+				Func: m[3],
+				Line: lineNum,
+				Path: m[1],
+			}
+			if inTrail {
+				trail = append(trail, TrailPoint{Frame: frame})
+			} else {
+				frames = append(frames, frame)
+			}
+			found = true
+			continue
+		}
+		// Error could have no own frames, but only frames of goroutine start.
+		if line == spawnTitle && !inTrail {
+			stacks = append(stacks, frames)
+			frames = nil
+			found = true
+			continue
+		}
+		if !found {
+			message = append(message, line)
+			continue
+		}
+		if m := repeatsPattern.FindStringSubmatch(line); m != nil {
+			cycle, _ := strconv.Atoi(m[1])
+			repeats, _ := strconv.Atoi(m[2])
+			if cycle == 0 || cycle > len(frames) {
+				return nil, errors.New("tracerr: invalid number of repeated frames")
+			}
+			if repeats > (maxParsedFrames-len(frames))/cycle {
+				return nil, errors.New("tracerr: too many repeated frames")
+			}
+			repeated := frames[len(frames)-cycle:]
+			for i := 0; i < repeats; i++ {
+				frames = append(frames, repeated...)
+			}
+			continue
+		}
+		if line == trailTitle {
+			inTrail = true
+			continue
+		}
+		if inTrail && len(trail) > 0 && strings.HasPrefix(line, "\t") {
+			trail[len(trail)-1].Note = line[1:]
+			continue
+		}
+		if m := omittedPattern.FindStringSubmatch(line); m != nil && len(stacks) == 0 {
+			dropped, _ = strconv.Atoi(m[1])
+			gap = len(frames)
+		}
+		// Everything else is a source fragment, a warning or an empty line.
+	}
+	if !found {
+		return nil, errors.New("tracerr: no frames found")
+	}
+	// Output with source has an empty line after message.
+	if len(message) > 1 && message[len(message)-1] == "" {
+		message = message[:len(message)-1]
+	}
+	stacks = append(stacks, frames)
+	e := CustomError(errors.New(strings.Join(message, "\n")), stacks[0]).(*errorData)
+	e.spawn = spawnChain(stacks[1:])
+	if dropped > 0 {
+		e.dropped = dropped
+		e.gap = gap
+	}
+	e.trail = restoreTrail(trail)
+	return e, nil
+}
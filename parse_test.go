@@ -0,0 +1,96 @@
+package tracerr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestParse(t *testing.T) {
+	frame := tracerr.Frame{Func: "main.walk", Line: 9, Path: "error_helper_test.go"}
+	repeated := tracerr.CustomError(
+		errors.New("repeated error"),
+		[]tracerr.Frame{frame, frame, frame},
+	)
+	var recurse func(n int) error
+	recurse = func(n int) error {
+		if n == 0 {
+			return tracerr.New("deep error")
+		}
+		return recurse(n - 1)
+	}
+	maxFrames := tracerr.MaxFrames
+	tracerr.MaxFrames = 20
+	dropped := recurse(100).(tracerr.Error)
+	tracerr.MaxFrames = maxFrames
+
+	errs := []tracerr.Error{
+		addFrameA("some error").(tracerr.Error),
+		tracerr.New("multiline\nerror"),
+		tracerr.CustomError(
+			errors.New("not found"),
+			[]tracerr.Frame{
+				{
+					Func: "main.(*Reader).Read",
+					Line: 42,
+					Path: "/tmp/not_exists.go",
+				},
+			},
+		),
+		repeated,
+		dropped,
+	}
+	printers := map[string]func(err error) string{
+		"Sprint": tracerr.Sprint,
+		"SprintSource": func(err error) string {
+			return tracerr.SprintSource(err)
+		},
+		"SprintSourceColor": func(err error) string {
+			return tracerr.SprintSourceColor(err, 1, 2)
+		},
+	}
+	for i, err := range errs {
+		for name, printer := range printers {
+			parsed, parseErr := tracerr.Parse(printer(err))
+			if parseErr != nil {
+				t.Errorf("tracerr.Parse(tracerr.%s(errs[%#v])) error = %#v", name, i, parseErr)
+				continue
+			}
+			if parsed.Error() != err.Error() {
+				t.Errorf(
+					"tracerr.Parse(tracerr.%s(errs[%#v])).Error() = %#v; want %#v",
+					name, i, parsed.Error(), err.Error(),
+				)
+			}
+			assertFrames(t, parsed.StackTrace(), err.StackTrace())
+			if tracerr.DroppedFrames(parsed) != tracerr.DroppedFrames(err) {
+				t.Errorf(
+					"tracerr.DroppedFrames(tracerr.Parse(tracerr.%s(errs[%#v]))) = %#v; want %#v",
+					name, i, tracerr.DroppedFrames(parsed), tracerr.DroppedFrames(err),
+				)
+			}
+			if printer(parsed) != printer(err) {
+				t.Errorf(
+					"tracerr.%s(tracerr.Parse(tracerr.%s(errs[%#v]))) = %#v; want %#v",
+					name, name, i, printer(parsed), printer(err),
+				)
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		"regular error",
+		"some error\nerror_helper_test.go:9 main.walk()\n[previous 2 frames repeated 1 time]",
+		"some error\nerror_helper_test.go:9 main.walk()\n[previous 0 frames repeated 1 time]",
+		"some error\nerror_helper_test.go:9 main.walk()\n[previous 1 frame repeated 2000000000 times]",
+	}
+	for i, text := range cases {
+		if _, err := tracerr.Parse(text); err == nil {
+			t.Errorf("tracerr.Parse(cases[%#v]) error = nil; want error", i)
+		}
+	}
+}
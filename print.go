@@ -3,7 +3,6 @@ package tracerr
 import (
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 )
@@ -14,13 +13,30 @@ var DefaultLinesAfter = 2
 // DefaultLinesBefore is number of source lines before traced line to display.
 var DefaultLinesBefore = 3
 
+// CollapseRepeated enables folding of repeated frames in output.
+// Consecutive identical frames and repeated cycles of frames,
+// which are typical for recursion, are printed only once
+// followed by a note with a number of repeats.
+// Source fragments are also shown only once for the same file and line.
+var CollapseRepeated = true
+
+// MaxRepeatedCycle is the longest cycle of frames detected by CollapseRepeated.
+var MaxRepeatedCycle = 16
+
+// FrameURL returns a link to source code of frame, such as on GitHub,
+// which is shown by print functions, SprintHTML and SprintMarkdown.
+// Frames are not linked if it's nil or returns an empty string.
+// See also Linker.
+var FrameURL func(frame Frame) string
+
 var cache = map[string][]string{}
 
 var mutex sync.RWMutex
 
 // Print prints error message with stack trace.
+// Repeated errors could be suppressed, see SuppressWindow.
 func Print(err error) {
-	fmt.Println(Sprint(err))
+	printSuppressed(err, false, Sprint)
 }
 
 // PrintSource prints error message with stack trace and source fragments.
@@ -33,14 +49,18 @@ func Print(err error) {
 // Pass two numbers to specify exactly how many lines should be shown
 // before and after traced line.
 func PrintSource(err error, nums ...int) {
-	fmt.Println(SprintSource(err, nums...))
+	printSuppressed(err, false, func(err error) string {
+		return SprintSource(err, nums...)
+	})
 }
 
 // PrintSourceColor prints error message with stack trace and source fragments,
 // which are in color.
 // Output rules are the same as in PrintSource.
 func PrintSourceColor(err error, nums ...int) {
-	fmt.Println(SprintSourceColor(err, nums...))
+	printSuppressed(err, true, func(err error) string {
+		return SprintSourceColor(err, nums...)
+	})
 }
 
 // Sprint returns error output by the same rules as Print.
@@ -105,83 +125,91 @@ func readLines(path string) ([]string, error) {
 	return lines, nil
 }
 
-func sourceRows(rows []string, frame Frame, before, after int, colorized bool) []string {
+// sourceFragment returns source lines around traced line of frame.
+func sourceFragment(frame Frame, before, after int) ([]SourceLine, error) {
 	lines, err := readLines(frame.Path)
 	if err != nil {
-		message := err.Error()
-		if colorized {
-			message = yellow(message)
-		}
-		return append(rows, message, "")
+		return nil, err
 	}
 	if len(lines) < frame.Line {
-		message := fmt.Sprintf(
+		return nil, fmt.Errorf(
 			"tracerr: too few lines, got %d, want %d",
 			len(lines), frame.Line,
 		)
-		if colorized {
-			message = yellow(message)
-		}
-		return append(rows, message, "")
 	}
 	current := frame.Line - 1
 	start := current - before
 	end := current + after
-	maxLine := end + 1
-	if maxLine > len(lines) {
-		maxLine = len(lines)
-	}
-	width := len(strconv.Itoa(maxLine))
+	fragment := make([]SourceLine, 0, before+after+1)
 	for i := start; i <= end; i++ {
 		if i < 0 || i >= len(lines) {
 			continue
 		}
-		line := lines[i]
-		var message string
-		lineNum := fmt.Sprintf("%*d", width, i+1)
-		if i == frame.Line-1 {
-			message = fmt.Sprintf("%s\t%s", lineNum, line)
-			if colorized {
-				message = red(message)
-			}
-		} else if colorized {
-			message = fmt.Sprintf("%s\t%s", black(lineNum), line)
-		} else {
-			message = fmt.Sprintf("%s\t%s", lineNum, line)
-		}
-		rows = append(rows, message)
+		fragment = append(fragment, SourceLine{
+			Number: i + 1,
+			Text:   lines[i],
+		})
 	}
-	return append(rows, "")
+	return fragment, nil
 }
 
 func sprint(err error, nums []int, colorized bool) string {
-	if err == nil {
+	r := BuildReport(err, NewReportOptions(nums...))
+	if colorized {
+		return RenderColor(r)
+	}
+	return RenderText(r)
+}
+
+// frameURL returns a link to source code of frame, if FrameURL is set.
+func frameURL(frame Frame) string {
+	if FrameURL == nil {
 		return ""
 	}
-	e, ok := err.(Error)
-	if !ok {
-		return err.Error()
+	return FrameURL(frame)
+}
+
+// findRepeats looks for a cycle of frames starting at frames[start],
+// which is immediately repeated at least once.
+// It returns the cycle length and the number of extra repeats,
+// preferring the cycle that folds the most frames.
+// If there is nothing worth folding, it returns 1 and 0.
+func findRepeats(frames []Frame, start int) (cycle, repeats int) {
+	cycle = 1
+	folded := 0
+	for size := 1; size <= MaxRepeatedCycle && start+size*2 <= len(frames); size++ {
+		n := 0
+		for next := start + size; next+size <= len(frames); next += size {
+			if !sameFrames(frames[start:start+size], frames[next:next+size]) {
+				break
+			}
+			n++
+		}
+		// Folding a single frame once saves nothing.
+		if n*size > folded && n*size > 1 {
+			cycle, repeats, folded = size, n, n*size
+		}
 	}
-	before, after, withSource := calcRows(nums)
-	frames := e.StackTrace()
-	expectedRows := len(frames) + 1
-	if withSource {
-		expectedRows = (before+after+3)*len(frames) + 2
+	return cycle, repeats
+}
+
+func sameFrames(a, b []Frame) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
 	}
-	rows := make([]string, 0, expectedRows)
-	rows = append(rows, e.Error())
-	if withSource {
-		rows = append(rows, "")
+	return true
+}
+
+func repeatsMessage(cycle, repeats int) string {
+	frames := "frames"
+	if cycle == 1 {
+		frames = "frame"
 	}
-	for _, frame := range frames {
-		message := frame.String()
-		if colorized {
-			message = bold(message)
-		}
-		rows = append(rows, message)
-		if withSource {
-			rows = sourceRows(rows, frame, before, after, colorized)
-		}
+	times := "times"
+	if repeats == 1 {
+		times = "time"
 	}
-	return strings.Join(rows, "\n")
+	return fmt.Sprintf("[previous %d %s repeated %d %s]", cycle, frames, repeats, times)
 }
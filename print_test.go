@@ -416,6 +416,108 @@ func TestNoSourceFileColor(t *testing.T) {
 	}
 }
 
+func TestRepeatedFrames(t *testing.T) {
+	frameA := tracerr.Frame{Func: "main.walk", Line: 9, Path: "error_helper_test.go"}
+	frameB := tracerr.Frame{Func: "main.visit", Line: 13, Path: "error_helper_test.go"}
+	frameC := tracerr.Frame{Func: "main.main", Line: 17, Path: "error_helper_test.go"}
+	frames := []tracerr.Frame{frameC, frameC, frameC}
+	for i := 0; i < 50; i++ {
+		frames = append(frames, frameA, frameB)
+	}
+	frames = append(frames, frameC)
+	err := tracerr.CustomError(errors.New("some error"), frames)
+
+	output := tracerr.Sprint(err)
+	expectedRows := []string{
+		"some error",
+		"error_helper_test.go:17 main.main()",
+		"[previous 1 frame repeated 2 times]",
+		"error_helper_test.go:9 main.walk()",
+		"error_helper_test.go:13 main.visit()",
+		"[previous 2 frames repeated 49 times]",
+		"error_helper_test.go:17 main.main()",
+	}
+	expected := strings.Join(expectedRows, "\n")
+	if output != expected {
+		t.Errorf(
+			"tracerr.Sprint(err) = %#v; want %#v",
+			output, expected,
+		)
+	}
+
+	output = tracerr.SprintSourceColor(err, 0)
+	expectedRows = []string{
+		"some error",
+		bold("error_helper_test.go:17 main.main()"),
+		black("[previous 1 frame repeated 2 times]"),
+		bold("error_helper_test.go:9 main.walk()"),
+		bold("error_helper_test.go:13 main.visit()"),
+		black("[previous 2 frames repeated 49 times]"),
+		bold("error_helper_test.go:17 main.main()"),
+	}
+	expected = strings.Join(expectedRows, "\n")
+	if output != expected {
+		t.Errorf(
+			"tracerr.SprintSourceColor(err, 0) = %#v; want %#v",
+			output, expected,
+		)
+	}
+
+	output = tracerr.SprintSource(err, 1)
+	expectedRows = []string{
+		"some error",
+		"",
+		"error_helper_test.go:17 main.main()",
+		"17\t\treturn tracerr.New(message)",
+		"",
+		"[previous 1 frame repeated 2 times]",
+		"",
+		"error_helper_test.go:9 main.walk()",
+		"9\t\treturn addFrameB(message)",
+		"",
+		"error_helper_test.go:13 main.visit()",
+		"13\t\treturn addFrameC(message)",
+		"",
+		"[previous 2 frames repeated 49 times]",
+		"",
+		"error_helper_test.go:17 main.main()",
+		"",
+	}
+	expected = strings.Join(expectedRows, "\n")
+	if output != expected {
+		t.Errorf(
+			"tracerr.SprintSource(err, 1) = %#v; want %#v",
+			output, expected,
+		)
+	}
+}
+
+func TestRepeatedFramesDisabled(t *testing.T) {
+	frame := tracerr.Frame{Func: "main.walk", Line: 9, Path: "error_helper_test.go"}
+	err := tracerr.CustomError(
+		errors.New("some error"),
+		[]tracerr.Frame{frame, frame, frame},
+	)
+	tracerr.CollapseRepeated = false
+	defer func() {
+		tracerr.CollapseRepeated = true
+	}()
+	output := tracerr.Sprint(err)
+	expectedRows := []string{
+		"some error",
+		"error_helper_test.go:9 main.walk()",
+		"error_helper_test.go:9 main.walk()",
+		"error_helper_test.go:9 main.walk()",
+	}
+	expected := strings.Join(expectedRows, "\n")
+	if output != expected {
+		t.Errorf(
+			"tracerr.Sprint(err) = %#v; want %#v",
+			output, expected,
+		)
+	}
+}
+
 func assertRows(t *testing.T, i int, output string, expectedRows []string, extra int) {
 	rows := strings.Split(output, "\n")
 	// There must be at least "extra" frames of test runner.
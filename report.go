@@ -0,0 +1,312 @@
+package tracerr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Report contains everything print functions output for an error,
+// so it could be rendered in any format.
+// See BuildReport, RenderText and RenderColor.
+type Report struct {
+	// Message is an error message.
+	Message string
+	// Chain contains messages of errors wrapped by the original error.
+	Chain []string
+	// Fingerprint identifies a place of failure, see Fingerprint.
+	// It's empty unless ReportOptions.Fingerprint is set,
+	// its code is shown in the header line otherwise.
+	Fingerprint string
+	// Traced is true if error has a stack trace, even if it's empty.
+	Traced bool
+	// WithSource is true if frames contain source fragments.
+	WithSource bool
+	// Frames contains frames and notes in order of output,
+	// followed by frames of goroutine starts, see Go.
+	Frames []ReportFrame
+	// Trail contains call sites, where error was wrapped on its way up, see Wrap.
+	Trail []TrailPoint
+}
+
+// ReportFrame is a frame of stack trace in Report.
+type ReportFrame struct {
+	Frame
+	// Note is a text, which is shown instead of frame,
+	// such as a number of folded repeats or omitted frames.
+	// Other fields are empty if it's set.
+	Note string
+	// URL is a link to source code, see FrameURL.
+	URL string
+	// Source contains source lines around traced line.
+	Source []SourceLine
+	// Highlight is an index of traced line in Source, or -1 if there is no such line.
+	Highlight int
+	// Warning contains a reason why source fragment is missing,
+	// such as file not found.
+	Warning string
+	// Repeated is true if source fragment is omitted,
+	// since it's already shown for the same frame above.
+	Repeated bool
+}
+
+// SourceLine is a line of source code.
+type SourceLine struct {
+	// Number is a line number, starting from 1.
+	Number int
+	// Text is a line of code.
+	Text string
+}
+
+// ReportOptions defines what BuildReport collects.
+type ReportOptions struct {
+	// Source enables source fragments of frames.
+	Source bool
+	// Before is a number of source lines before traced line.
+	Before int
+	// After is a number of source lines after traced line.
+	After int
+	// Fingerprint enables Report.Fingerprint.
+	Fingerprint bool
+}
+
+// NewReportOptions returns options with source fragments,
+// which size is defined by the same rules as in PrintSource.
+// Pass 0 to disable source fragments.
+// Fingerprint is enabled if ShowFingerprint is set.
+func NewReportOptions(nums ...int) ReportOptions {
+	before, after, withSource := calcRows(nums)
+	return ReportOptions{
+		Source:      withSource,
+		Before:      before,
+		After:       after,
+		Fingerprint: ShowFingerprint,
+	}
+}
+
+// BuildReport collects message, wrapped errors and frames of err.
+// Frames are folded according to CollapseRepeated,
+// and omitted frames of deep stacks are noted.
+// It returns nil if err is nil.
+func BuildReport(err error, opts ReportOptions) *Report {
+	if err == nil {
+		return nil
+	}
+	r := &Report{
+		Message: err.Error(),
+		Chain:   chain(err),
+	}
+	e, ok := err.(Error)
+	if !ok {
+		return r
+	}
+	r.Traced = true
+	r.WithSource = opts.Source
+	if opts.Fingerprint {
+		r.Fingerprint = Fingerprint(e)
+	}
+	frames := e.StackTrace()
+	r.Frames = make([]ReportFrame, 0, len(frames))
+	var shown map[Frame]bool
+	if opts.Source && CollapseRepeated {
+		shown = map[Frame]bool{}
+	}
+	frame := func(frame Frame) {
+		rf := ReportFrame{
+			Frame:     frame,
+			URL:       frameURL(frame),
+			Highlight: -1,
+		}
+		switch {
+		case !opts.Source:
+		case shown[frame]:
+			rf.Repeated = true
+		default:
+			if shown != nil {
+				shown[frame] = true
+			}
+			fragment, err := sourceFragment(frame, opts.Before, opts.After)
+			if err != nil {
+				rf.Warning = err.Error()
+			}
+			rf.Source = fragment
+			for i, line := range fragment {
+				if line.Number == frame.Line {
+					rf.Highlight = i
+				}
+			}
+		}
+		r.Frames = append(r.Frames, rf)
+	}
+	note := func(message string) {
+		r.Frames = append(r.Frames, ReportFrame{Note: message, Highlight: -1})
+	}
+	walkFrames(e, frames, frame, note)
+	if d, ok := e.(*errorData); ok {
+		for s := d.spawn; s != nil; s = s.spawn {
+			note(spawnTitle)
+			walkFrames(s, s.StackTrace(), frame, note)
+		}
+	}
+	r.Trail = Trail(e)
+	return r
+}
+
+// walkFrames calls frame for each frame to output
+// and note for folded repeats and dropped frames in between.
+func walkFrames(e Error, frames []Frame, frame func(Frame), note func(string)) {
+	at, dropped := 0, 0
+	if d, ok := e.(*errorData); ok {
+		at, dropped = d.droppedFrames()
+	}
+	if dropped > 0 {
+		foldFrames(frames[:at], frame, note)
+		note(fmt.Sprintf("[... %d frames omitted ...]", dropped))
+		foldFrames(frames[at:], frame, note)
+	} else {
+		foldFrames(frames, frame, note)
+	}
+}
+
+// foldFrames calls frame for each frame and note for folded repeats,
+// see CollapseRepeated.
+func foldFrames(frames []Frame, frame func(Frame), note func(string)) {
+	for i := 0; i < len(frames); {
+		cycle, repeats := 1, 0
+		if CollapseRepeated {
+			cycle, repeats = findRepeats(frames, i)
+		}
+		for _, f := range frames[i : i+cycle] {
+			frame(f)
+		}
+		if repeats > 0 {
+			note(repeatsMessage(cycle, repeats))
+		}
+		i += cycle * (repeats + 1)
+	}
+}
+
+// RenderText returns report in the format of Sprint and SprintSource.
+func RenderText(r *Report) string {
+	return renderText(r, false)
+}
+
+// RenderColor returns report in the format of SprintSourceColor.
+func RenderColor(r *Report) string {
+	return renderText(r, true)
+}
+
+func renderText(r *Report, colorized bool) string {
+	if r == nil {
+		return ""
+	}
+	if !r.Traced {
+		return r.Message
+	}
+	expectedRows := len(r.Frames) + 1
+	if r.WithSource {
+		expectedRows = (len(r.Frames)+1)*2 + len(r.Frames)*(DefaultLinesBefore+DefaultLinesAfter+1)
+	}
+	rows := make([]string, 0, expectedRows)
+	header := r.Message
+	if r.Fingerprint != "" {
+		code := "[" + fingerprintCode(r.Fingerprint) + "]"
+		if colorized {
+			code = black(code)
+		}
+		header += " " + code
+	}
+	rows = append(rows, header)
+	if r.WithSource {
+		rows = append(rows, "")
+	}
+	for _, f := range r.Frames {
+		if f.Note != "" {
+			message := f.Note
+			if colorized {
+				message = black(message)
+			}
+			rows = append(rows, message)
+			if r.WithSource {
+				rows = append(rows, "")
+			}
+			continue
+		}
+		message := f.Frame.String()
+		if colorized {
+			message = hyperlink(f.Frame, bold(message))
+		}
+		rows = append(rows, message)
+		if f.URL != "" {
+			url := f.URL
+			if colorized {
+				url = black(url)
+			}
+			rows = append(rows, "\t"+url)
+		}
+		if r.WithSource {
+			rows = append(sourceRows(rows, f, colorized), "")
+		}
+	}
+	if len(r.Trail) > 0 {
+		rows = trailRows(rows, r.Trail, colorized)
+		if r.WithSource {
+			rows = append(rows, "")
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// trailTitle is a row before trail in text output.
+const trailTitle = "returned through:"
+
+// trailRows adds rows of call sites, where error was wrapped, with notes.
+func trailRows(rows []string, trail []TrailPoint, colorized bool) []string {
+	title := trailTitle
+	if colorized {
+		title = black(title)
+	}
+	rows = append(rows, title)
+	for _, p := range trail {
+		message := p.Frame.String()
+		if colorized {
+			message = hyperlink(p.Frame, bold(message))
+		}
+		rows = append(rows, message)
+		if p.Note != "" {
+			rows = append(rows, "\t"+p.Note)
+		}
+	}
+	return rows
+}
+
+// sourceRows adds rows of source fragment or a warning.
+func sourceRows(rows []string, f ReportFrame, colorized bool) []string {
+	if f.Warning != "" {
+		message := f.Warning
+		if colorized {
+			message = yellow(message)
+		}
+		return append(rows, message)
+	}
+	if len(f.Source) == 0 {
+		return rows
+	}
+	width := len(strconv.Itoa(f.Source[len(f.Source)-1].Number))
+	for i, line := range f.Source {
+		lineNum := fmt.Sprintf("%*d", width, line.Number)
+		var message string
+		if i == f.Highlight {
+			message = fmt.Sprintf("%s\t%s", lineNum, line.Text)
+			if colorized {
+				message = red(message)
+			}
+		} else if colorized {
+			message = fmt.Sprintf("%s\t%s", black(lineNum), line.Text)
+		} else {
+			message = fmt.Sprintf("%s\t%s", lineNum, line.Text)
+		}
+		rows = append(rows, message)
+	}
+	return rows
+}
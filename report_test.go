@@ -0,0 +1,104 @@
+package tracerr_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestBuildReport(t *testing.T) {
+	frame := tracerr.Frame{Func: "main.walk", Line: 9, Path: "error_helper_test.go"}
+	missing := tracerr.Frame{Func: "main.main", Line: 42, Path: "/tmp/not_exists.go"}
+	err := tracerr.CustomError(
+		fmt.Errorf("failed: %w", errors.New("cause")),
+		[]tracerr.Frame{frame, missing, frame, missing, frame, missing},
+	)
+	r := tracerr.BuildReport(err, tracerr.NewReportOptions(2, 1))
+	expected := &tracerr.Report{
+		Message:    "failed: cause",
+		Chain:      []string{"cause"},
+		Traced:     true,
+		WithSource: true,
+		Frames: []tracerr.ReportFrame{
+			{
+				Frame: frame,
+				Source: []tracerr.SourceLine{
+					{Number: 7, Text: ""},
+					{Number: 8, Text: "func addFrameA(message string) error {"},
+					{Number: 9, Text: "\treturn addFrameB(message)"},
+					{Number: 10, Text: "}"},
+				},
+				Highlight: 2,
+			},
+			{
+				Frame:     missing,
+				Highlight: -1,
+				Warning:   "tracerr: file /tmp/not_exists.go not found",
+			},
+			{
+				Note:      "[previous 2 frames repeated 2 times]",
+				Highlight: -1,
+			},
+		},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("tracerr.BuildReport(err, opts) = %#v; want %#v", r, expected)
+	}
+	if tracerr.RenderText(r) != tracerr.SprintSource(err, 2, 1) {
+		t.Errorf("tracerr.RenderText(r) = %#v; want %#v", tracerr.RenderText(r), tracerr.SprintSource(err, 2, 1))
+	}
+	if tracerr.RenderColor(r) != tracerr.SprintSourceColor(err, 2, 1) {
+		t.Errorf("tracerr.RenderColor(r) = %#v; want %#v", tracerr.RenderColor(r), tracerr.SprintSourceColor(err, 2, 1))
+	}
+
+	r = tracerr.BuildReport(err, tracerr.ReportOptions{Fingerprint: true})
+	if r.Fingerprint != tracerr.Fingerprint(err) {
+		t.Errorf("r.Fingerprint = %#v; want %#v", r.Fingerprint, tracerr.Fingerprint(err))
+	}
+	header := "failed: cause [" + tracerr.FingerprintCode(err) + "]\n"
+	if !strings.HasPrefix(tracerr.RenderText(r), header) {
+		t.Errorf("tracerr.RenderText(r) = %#v; want prefix %#v", tracerr.RenderText(r), header)
+	}
+
+	tracerr.CollapseRepeated = false
+	r = tracerr.BuildReport(err, tracerr.ReportOptions{})
+	output := tracerr.Sprint(err)
+	tracerr.CollapseRepeated = true
+	if r.WithSource || len(r.Frames) != 6 || r.Frames[0].Source != nil || r.Frames[0].Highlight != -1 {
+		t.Errorf("tracerr.BuildReport(err, ReportOptions{}) = %#v; want all frames without source", r)
+	}
+	if tracerr.RenderText(r) != output {
+		t.Errorf("tracerr.RenderText(r) = %#v; want %#v", tracerr.RenderText(r), output)
+	}
+
+	r = tracerr.BuildReport(errors.New("regular error"), tracerr.NewReportOptions())
+	if r.Traced || r.Message != "regular error" || r.Frames != nil {
+		t.Errorf("tracerr.BuildReport(regular error, opts) = %#v", r)
+	}
+	if r := tracerr.BuildReport(nil, tracerr.NewReportOptions()); r != nil {
+		t.Errorf("tracerr.BuildReport(nil, opts) = %#v; want nil", r)
+	}
+}
+
+func TestCustomRenderer(t *testing.T) {
+	err := tracerr.CustomError(errors.New("some error"), []tracerr.Frame{
+		{Func: "main.walk", Line: 9, Path: "error_helper_test.go"},
+	})
+	r := tracerr.BuildReport(err, tracerr.NewReportOptions(1))
+	var b strings.Builder
+	b.WriteString("ERROR " + r.Message + "\n")
+	for _, f := range r.Frames {
+		fmt.Fprintf(&b, "  at %s (%s:%d)\n", f.Func, f.Path, f.Line)
+		if f.Highlight >= 0 {
+			fmt.Fprintf(&b, "    %s\n", strings.TrimSpace(f.Source[f.Highlight].Text))
+		}
+	}
+	expected := "ERROR some error\n  at main.walk (error_helper_test.go:9)\n    return addFrameB(message)\n"
+	if b.String() != expected {
+		t.Errorf("output = %#v; want %#v", b.String(), expected)
+	}
+}
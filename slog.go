@@ -0,0 +1,197 @@
+package tracerr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+)
+
+// LogValue returns error as a group of message, kind and frames for log/slog.
+func (e *errorData) LogValue() slog.Value {
+	return errorValue(e)
+}
+
+// ReplaceAttr expands attributes with errors, which contain a stack trace
+// somewhere in chain, into groups the same way as Error.LogValue.
+// It's meant to be used as slog.HandlerOptions.ReplaceAttr.
+func ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if err := tracedError(a.Value); err != nil {
+		a.Value = errorValue(err)
+	}
+	return a
+}
+
+// SlogOptions defines how NewSlogHandler handles errors.
+type SlogOptions struct {
+	// Trace receives multi-line output of errors after each record,
+	// while only error message is passed to the handler.
+	// It's useful for text handlers in development.
+	// If it's not set, errors are expanded into groups, which fits JSON handlers.
+	Trace io.Writer
+	// Sprint returns output of errors for Trace, Sprint is used by default.
+	Sprint func(err error) string
+}
+
+// slogHandler expands errors in attributes of records.
+type slogHandler struct {
+	handler slog.Handler
+	opts    SlogOptions
+	// traced contains errors from attributes added by WithAttrs.
+	traced []error
+	mutex  *sync.Mutex
+}
+
+// NewSlogHandler returns a handler that passes records to h,
+// expanding errors, which contain a stack trace somewhere in chain,
+// into groups of message, kind and frames.
+// If opts.Trace is set, errors are written there in multi-line form instead.
+func NewSlogHandler(h slog.Handler, opts *SlogOptions) slog.Handler {
+	handler := &slogHandler{
+		handler: h,
+		mutex:   &sync.Mutex{},
+	}
+	if opts != nil {
+		handler.opts = *opts
+	}
+	if handler.opts.Sprint == nil {
+		handler.opts.Sprint = Sprint
+	}
+	return handler
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	traced := h.traced
+	record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		record.AddAttrs(h.replace(a, &traced))
+		return true
+	})
+	if err := h.handler.Handle(ctx, record); err != nil {
+		return err
+	}
+	if h.opts.Trace == nil || len(traced) == 0 {
+		return nil
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, err := range traced {
+		if _, writeErr := io.WriteString(h.opts.Trace, h.opts.Sprint(err)+"\n"); writeErr != nil {
+			return writeErr
+		}
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.traced = append([]error(nil), h.traced...)
+	replaced := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		replaced[i] = h.replace(a, &handler.traced)
+	}
+	handler.handler = h.handler.WithAttrs(replaced)
+	return &handler
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	handler := *h
+	handler.handler = h.handler.WithGroup(name)
+	return &handler
+}
+
+// replace expands errors in attribute and groups within it.
+// Errors to be written to Trace are added to traced.
+func (h *slogHandler) replace(a slog.Attr, traced *[]error) slog.Attr {
+	if err := tracedError(a.Value); err != nil {
+		if h.opts.Trace == nil {
+			a.Value = errorValue(err)
+		} else {
+			a.Value = slog.StringValue(err.Error())
+			*traced = append(*traced, err)
+		}
+		return a
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		replaced := make([]slog.Attr, len(group))
+		for i, attr := range group {
+			replaced[i] = h.replace(attr, traced)
+		}
+		a.Value = slog.GroupValue(replaced...)
+	}
+	return a
+}
+
+// tracedError returns error contained in value, if it has a stack trace.
+func tracedError(v slog.Value) error {
+	if v.Kind() != slog.KindAny && v.Kind() != slog.KindLogValuer {
+		return nil
+	}
+	err, ok := v.Any().(error)
+	if !ok || originError(err) == nil {
+		return nil
+	}
+	return err
+}
+
+// errorValue returns a group of error message, kind, chain, frames,
+// goroutine starts and trail.
+func errorValue(err error) slog.Value {
+	attrs := []slog.Attr{
+		slog.String("message", err.Error()),
+	}
+	original := err
+	if e, ok := err.(Error); ok {
+		original = e.Unwrap()
+	}
+	if original != nil {
+		attrs = append(attrs, slog.String("kind", fmt.Sprintf("%T", original)))
+		if messages := chain(err); len(messages) > 0 {
+			attrs = append(attrs, slog.Any("chain", messages))
+		}
+	}
+	origin := originError(err)
+	if origin == nil {
+		return slog.GroupValue(attrs...)
+	}
+	attrs = append(attrs, slog.String("fingerprint", Fingerprint(origin)))
+	if dropped := DroppedFrames(origin); dropped > 0 {
+		attrs = append(attrs, slog.Int("dropped", dropped))
+	}
+	frames := origin.StackTrace()
+	frameAttrs := make([]slog.Attr, len(frames))
+	for i, frame := range frames {
+		frameAttrs[i] = slog.String(strconv.Itoa(i), frame.String())
+	}
+	attrs = append(attrs, slog.Attr{Key: "frames", Value: slog.GroupValue(frameAttrs...)})
+	if stacks := StartedBy(origin); len(stacks) > 0 {
+		stackAttrs := make([]slog.Attr, len(stacks))
+		for i, stack := range stacks {
+			spawnAttrs := make([]slog.Attr, len(stack))
+			for j, frame := range stack {
+				spawnAttrs[j] = slog.String(strconv.Itoa(j), frame.String())
+			}
+			stackAttrs[i] = slog.Attr{Key: strconv.Itoa(i), Value: slog.GroupValue(spawnAttrs...)}
+		}
+		attrs = append(attrs, slog.Attr{Key: "started_by", Value: slog.GroupValue(stackAttrs...)})
+	}
+	if trail := Trail(origin); len(trail) > 0 {
+		trailAttrs := make([]slog.Attr, len(trail))
+		for i, p := range trail {
+			point := p.Frame.String()
+			if p.Note != "" {
+				point += ": " + p.Note
+			}
+			trailAttrs[i] = slog.String(strconv.Itoa(i), point)
+		}
+		attrs = append(attrs, slog.Attr{Key: "trail", Value: slog.GroupValue(trailAttrs...)})
+	}
+	return slog.GroupValue(attrs...)
+}
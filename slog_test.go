@@ -0,0 +1,117 @@
+package tracerr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+type slogEntry struct {
+	Msg string
+	Err struct {
+		Message     string
+		Kind        string
+		Chain       []string
+		Fingerprint string
+		Frames      map[string]string
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	err := tracerr.Wrap(fmt.Errorf("read config: %w", errors.New("file not found")))
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", "err", err)
+
+	var entry slogEntry
+	if unmarshalErr := json.Unmarshal(buf.Bytes(), &entry); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %#v", buf.Bytes(), unmarshalErr)
+	}
+	assertSlogEntry(t, entry, err, "*fmt.wrapError")
+	if fmt.Sprint(entry.Err.Chain) != "[file not found]" {
+		t.Errorf("entry.Err.Chain = %#v; want %#v", entry.Err.Chain, []string{"file not found"})
+	}
+}
+
+func TestReplaceAttr(t *testing.T) {
+	err := fmt.Errorf("handle request: %w", tracerr.New("some error"))
+	var buf bytes.Buffer
+	opts := &slog.HandlerOptions{ReplaceAttr: tracerr.ReplaceAttr}
+	slog.New(slog.NewJSONHandler(&buf, opts)).Error("failed", "err", err, "other", errors.New("regular error"))
+
+	var entry slogEntry
+	if unmarshalErr := json.Unmarshal(buf.Bytes(), &entry); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %#v", buf.Bytes(), unmarshalErr)
+	}
+	assertSlogEntry(t, entry, err, "*fmt.wrapError")
+	if !strings.Contains(buf.String(), `"other":"regular error"`) {
+		t.Errorf("output = %s; want regular error untouched", buf.Bytes())
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	err := fmt.Errorf("handle request: %w", tracerr.New("some error"))
+
+	var buf bytes.Buffer
+	logger := slog.New(tracerr.NewSlogHandler(slog.NewJSONHandler(&buf, nil), nil))
+	logger.WithGroup("request").Error("failed", "err", err)
+	var entry struct {
+		Request slogEntry
+	}
+	if unmarshalErr := json.Unmarshal(buf.Bytes(), &entry); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %#v", buf.Bytes(), unmarshalErr)
+	}
+	assertSlogEntry(t, entry.Request, err, "*fmt.wrapError")
+
+	var out, trace bytes.Buffer
+	textHandler := slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger = slog.New(tracerr.NewSlogHandler(textHandler, &tracerr.SlogOptions{Trace: &trace}))
+	logger.With("cause", err).Error("failed", "group", slog.GroupValue(slog.Any("err", err)))
+	expected := `level=ERROR msg=failed cause="handle request: some error" group.err="handle request: some error"` + "\n"
+	if out.String() != expected {
+		t.Errorf("output = %#v; want %#v", out.String(), expected)
+	}
+	expectedTrace := tracerr.Sprint(err) + "\n"
+	if trace.String() != expectedTrace+expectedTrace {
+		t.Errorf("trace = %#v; want %#v", trace.String(), expectedTrace+expectedTrace)
+	}
+}
+
+func assertSlogEntry(t *testing.T, entry slogEntry, err error, kind string) {
+	t.Helper()
+	if entry.Err.Message != err.Error() {
+		t.Errorf("entry.Err.Message = %#v; want %#v", entry.Err.Message, err.Error())
+	}
+	if entry.Err.Kind != kind {
+		t.Errorf("entry.Err.Kind = %#v; want %#v", entry.Err.Kind, kind)
+	}
+	if entry.Err.Fingerprint != tracerr.Fingerprint(err) {
+		t.Errorf("entry.Err.Fingerprint = %#v; want %#v", entry.Err.Fingerprint, tracerr.Fingerprint(err))
+	}
+	var stackTrace []tracerr.Frame
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if traced, ok := e.(tracerr.Error); ok {
+			stackTrace = traced.StackTrace()
+		}
+	}
+	if len(entry.Err.Frames) != len(stackTrace) {
+		t.Fatalf("len(entry.Err.Frames) = %#v; want %#v", len(entry.Err.Frames), len(stackTrace))
+	}
+	for i, frame := range stackTrace {
+		if entry.Err.Frames[fmt.Sprint(i)] != frame.String() {
+			t.Errorf("entry.Err.Frames[%#v] = %#v; want %#v", i, entry.Err.Frames[fmt.Sprint(i)], frame.String())
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package tracerr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// spawnTitle is a note before frames of a goroutine start in output.
+const spawnTitle = "started by:"
+
+// spawnKey is a context key of a goroutine start, see GoCtx.
+type spawnKey struct{}
+
+var (
+	// spawns contains starts of goroutines by goroutine ID,
+	// while they are running.
+	spawns sync.Map
+	// running is a number of running goroutines started by Go or GoCtx.
+	running int64
+)
+
+// Go calls fn in a new goroutine and returns a channel,
+// which receives its result and is closed after that.
+// Panic in fn is recovered and received as an error with stack trace of the panic.
+//
+// Stack trace of the goroutine start is attached to the error,
+// so printers output it after frames of the goroutine, see StartedBy.
+// If the current goroutine is started by Go or GoCtx as well,
+// its start is attached after that, and so on.
+func Go(fn func() error) <-chan error {
+	return start(spawn(nil), fn)
+}
+
+// GoCtx works the same way as Go, but also passes ctx to fn.
+// The goroutine start is stored in context,
+// so starts of goroutines started with GoCtx using this context are chained to it,
+// even if GoCtx is called in another goroutine.
+func GoCtx(ctx context.Context, fn func(ctx context.Context) error) <-chan error {
+	s := spawn(ctx)
+	ctx = context.WithValue(ctx, spawnKey{}, s)
+	return start(s, func() error {
+		return fn(ctx)
+	})
+}
+
+// StartedBy returns stack traces of goroutine starts, where err is produced,
+// the nearest one first, see Go.
+// It will be empty if err is not of type Error.
+func StartedBy(err error) [][]Frame {
+	e, ok := err.(*errorData)
+	if !ok {
+		return nil
+	}
+	var stacks [][]Frame
+	for s := e.spawn; s != nil; s = s.spawn {
+		stacks = append(stacks, s.StackTrace())
+	}
+	return stacks
+}
+
+// spawn captures stack trace of the caller of Go or GoCtx,
+// chained to the start of the current goroutine if it's known.
+func spawn(ctx context.Context) *errorData {
+	s := trace(nil, 3).(*errorData)
+	if ctx != nil {
+		if parent, ok := ctx.Value(spawnKey{}).(*errorData); ok {
+			s.spawn = parent
+			return s
+		}
+	}
+	if atomic.LoadInt64(&running) > 0 {
+		if parent, ok := spawns.Load(goroutineID()); ok {
+			s.spawn = parent.(*errorData)
+		}
+	}
+	return s
+}
+
+// start calls fn in a new goroutine, which start is s.
+func start(s *errorData, fn func() error) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		id := goroutineID()
+		if id != 0 {
+			spawns.Store(id, s)
+			atomic.AddInt64(&running, 1)
+		}
+		defer func() {
+			if id != 0 {
+				atomic.AddInt64(&running, -1)
+				spawns.Delete(id)
+			}
+			close(result)
+		}()
+		result <- run(s, fn)
+	}()
+	return result
+}
+
+// run calls fn, recovers its panic and attaches goroutine start s to the error.
+func run(s *errorData, fn func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = Recovered(p)
+		}
+		if err != nil {
+			err = withSpawn(err, s)
+		}
+	}()
+	return fn()
+}
+
+// Recovered converts a value returned by recover into an error with stack trace of the panic,
+// starting at the panicking function, without frames of deferred functions and runtime.
+// Errors of type Error keep their stack trace.
+// It should be called in the deferred function, which recovers the panic:
+//
+//	defer func() {
+//		if p := recover(); p != nil {
+//			err = tracerr.Recovered(p)
+//		}
+//	}()
+//
+// It returns nil if p is nil.
+func Recovered(p interface{}) Error {
+	if p == nil {
+		return nil
+	}
+	if e, ok := p.(Error); ok {
+		return e
+	}
+	err, ok := p.(error)
+	if !ok {
+		err = fmt.Errorf("%v", p)
+	}
+	e := trace(err, 2)
+	frames := e.StackTrace()
+	for i, frame := range frames {
+		if frame.Func != "runtime.gopanic" {
+			continue
+		}
+		// Runtime errors, such as index out of range, are raised by runtime functions.
+		i++
+		for i < len(frames) && strings.HasPrefix(frames[i].Func, "runtime.") {
+			i++
+		}
+		return CustomError(err, frames[i:])
+	}
+	return e
+}
+
+// withSpawn returns an error with goroutine start s,
+// unless err is already produced by another goroutine started by Go or GoCtx.
+// Errors without stack trace have only the goroutine start.
+func withSpawn(err error, s *errorData) error {
+	switch e := err.(type) {
+	case *errorData:
+		if e.spawn != nil {
+			return e
+		}
+		d := derive(e, e.err)
+		d.spawn = s
+		return d
+	case Error:
+		d := CustomError(e.Unwrap(), e.StackTrace()).(*errorData)
+		d.spawn = s
+		return d
+	}
+	return &errorData{
+		err:    err,
+		frames: []Frame{},
+		spawn:  s,
+	}
+}
+
+// spawnChain restores goroutine starts from their stack traces,
+// the nearest one first.
+func spawnChain(stacks [][]Frame) *errorData {
+	var s *errorData
+	for i := len(stacks) - 1; i >= 0; i-- {
+		parent := s
+		s = CustomError(nil, stacks[i]).(*errorData)
+		s.spawn = parent
+	}
+	return s
+}
+
+// goroutineID returns ID of the current goroutine, or zero if it's unknown.
+// Runtime doesn't expose it, but the header of goroutine trace is stable
+// since Go 1.0, and ParsePanic relies on it as well.
+// It's only used by Go and GoCtx, so creation of regular errors doesn't pay for it.
+// If the format ever changes, goroutine starts are just not chained.
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	// The first line is "goroutine 123 [running]:".
+	fields := bytes.Fields(buf[:n])
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(fields[1]), 10, 64)
+	return id
+}
@@ -0,0 +1,171 @@
+package tracerr_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestGo(t *testing.T) {
+	_, path, line, _ := runtime.Caller(0)
+	result := tracerr.Go(func() error {
+		return tracerr.New("some error")
+	})
+	err := <-result
+	if _, ok := <-result; ok {
+		t.Error("result is not closed")
+	}
+	if err == nil || err.Error() != "some error" {
+		t.Fatalf("<-tracerr.Go(fn) = %#v; want some error", err)
+	}
+	frames := tracerr.StackTrace(err)
+	if len(frames) == 0 || frames[0].Line != line+2 {
+		t.Errorf("tracerr.StackTrace(err) = %#v; want frames of fn", frames)
+	}
+	stacks := tracerr.StartedBy(err)
+	expected := tracerr.Frame{Func: "github.com/ztrue/tracerr_test.TestGo", Line: line + 1, Path: path}
+	if len(stacks) != 1 || len(stacks[0]) == 0 || stacks[0][0] != expected {
+		t.Fatalf("tracerr.StartedBy(err) = %#v; want %#v first", stacks, expected)
+	}
+
+	output := tracerr.Sprint(err)
+	if !strings.Contains(output, "\nstarted by:\n"+expected.String()+"\n") {
+		t.Errorf("tracerr.Sprint(err) = %#v; want goroutine start", output)
+	}
+	goroutine := tracerr.SprintGoroutine(err)
+	if !strings.HasSuffix(goroutine, "\ncreated by "+expected.Func+"\n\t"+path+":"+strconv.Itoa(expected.Line)) {
+		t.Errorf("tracerr.SprintGoroutine(err) = %#v; want created by", goroutine)
+	}
+
+	parsed, parseErr := tracerr.Parse(tracerr.SprintSourceColor(err))
+	if parseErr != nil {
+		t.Fatalf("tracerr.Parse() error = %v", parseErr)
+	}
+	if !reflect.DeepEqual(parsed.StackTrace(), frames) {
+		t.Errorf("parsed.StackTrace() = %#v; want %#v", parsed.StackTrace(), frames)
+	}
+	if !reflect.DeepEqual(tracerr.StartedBy(parsed), stacks) {
+		t.Errorf("tracerr.StartedBy(parsed) = %#v; want %#v", tracerr.StartedBy(parsed), stacks)
+	}
+
+	data, jsonErr := tracerr.MarshalJSON(err)
+	if jsonErr != nil {
+		t.Fatalf("tracerr.MarshalJSON() error = %v", jsonErr)
+	}
+	decoded, jsonErr := tracerr.UnmarshalJSON(data)
+	if jsonErr != nil {
+		t.Fatalf("tracerr.UnmarshalJSON() error = %v", jsonErr)
+	}
+	if !reflect.DeepEqual(tracerr.StartedBy(decoded), stacks) {
+		t.Errorf("tracerr.StartedBy(decoded) = %#v; want %#v", tracerr.StartedBy(decoded), stacks)
+	}
+
+	if err := <-tracerr.Go(func() error { return nil }); err != nil {
+		t.Errorf("<-tracerr.Go(fn) = %#v; want nil", err)
+	}
+}
+
+func TestGoNested(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	err := <-tracerr.Go(func() error {
+		return <-tracerr.Go(func() error {
+			return io.EOF
+		})
+	})
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(err, io.EOF) = false; want true")
+	}
+	stacks := tracerr.StartedBy(err)
+	if len(stacks) != 2 || stacks[0][0].Line != line+2 || stacks[1][0].Line != line+1 {
+		t.Errorf("tracerr.StartedBy(err) = %#v; want nested and outer starts", stacks)
+	}
+	if frames := tracerr.StackTrace(err); len(frames) != 0 {
+		t.Errorf("tracerr.StackTrace(err) = %#v; want no frames", frames)
+	}
+}
+
+func TestGoCtx(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	_, _, line, _ := runtime.Caller(0)
+	err := <-tracerr.GoCtx(ctx, func(ctx context.Context) error {
+		inner := make(chan error)
+		// Starts are chained by context even in goroutines started without it.
+		go func() {
+			inner <- <-tracerr.GoCtx(ctx, func(ctx context.Context) error {
+				return tracerr.Errorf("%v", ctx.Value(key{}))
+			})
+		}()
+		return <-inner
+	})
+	if err == nil || err.Error() != "value" {
+		t.Fatalf("<-tracerr.GoCtx(ctx, fn) = %#v; want value", err)
+	}
+	stacks := tracerr.StartedBy(err)
+	if len(stacks) != 2 || stacks[0][0].Line != line+5 || stacks[1][0].Line != line+1 {
+		t.Errorf("tracerr.StartedBy(err) = %#v; want nested and outer starts", stacks)
+	}
+}
+
+func TestGoPanic(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	err := <-tracerr.Go(func() error {
+		var values []int
+		return errors.New(string(rune(values[1])))
+	})
+	var runtimeErr runtime.Error
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("<-tracerr.Go(fn) = %#v; want runtime error", err)
+	}
+	frames := tracerr.StackTrace(err)
+	if len(frames) == 0 || frames[0].Line != line+3 {
+		t.Errorf("tracerr.StackTrace(err) = %#v; want panicking line first", frames)
+	}
+	if len(tracerr.StartedBy(err)) != 1 {
+		t.Errorf("tracerr.StartedBy(err) = %#v; want goroutine start", tracerr.StartedBy(err))
+	}
+
+	err = <-tracerr.Go(func() error {
+		panic("some panic")
+	})
+	if err == nil || err.Error() != "some panic" {
+		t.Errorf("<-tracerr.Go(fn) = %#v; want some panic", err)
+	}
+}
+
+func TestRecovered(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	err := func() (err error) {
+		defer func() {
+			err = tracerr.Recovered(recover())
+		}()
+		var values []int
+		return errors.New(string(rune(values[1])))
+	}()
+	var runtimeErr runtime.Error
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("tracerr.Recovered(p) = %#v; want runtime error", err)
+	}
+	frames := tracerr.StackTrace(err)
+	if len(frames) == 0 || frames[0].Line != line+6 {
+		t.Errorf("tracerr.StackTrace(err) = %#v; want panicking line first", frames)
+	}
+
+	origin := tracerr.New("some error")
+	if err := tracerr.Recovered(origin); err != origin {
+		t.Errorf("tracerr.Recovered(origin) = %#v; want %#v", err, origin)
+	}
+	if err := tracerr.Recovered("some panic"); err == nil || err.Error() != "some panic" {
+		t.Errorf("tracerr.Recovered(some panic) = %#v; want some panic", err)
+	}
+	if err := tracerr.Recovered(nil); err != nil {
+		t.Errorf("tracerr.Recovered(nil) = %#v; want nil", err)
+	}
+}
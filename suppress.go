@@ -0,0 +1,144 @@
+package tracerr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SuppressWindow enables suppression of repeated errors in Print functions.
+//
+// If an error with the same fingerprint was printed within the window,
+// a single line such as "same error as above (x57 in last 60s)"
+// is printed instead of the full output.
+// When the window is over, a summary of suppressed errors is printed,
+// even if nothing else is printed after that, see also FlushSuppressed.
+//
+// Errors without stack trace are never suppressed.
+// Suppression is disabled by default, Sprint functions are not affected.
+var SuppressWindow time.Duration
+
+// suppression tracks repeats of an error within the window.
+type suppression struct {
+	message string
+	code    string
+	printed time.Time
+	last    time.Time
+	// count is a number of suppressed errors.
+	count int
+	// window is SuppressWindow at the time the error is printed.
+	window time.Duration
+	// timer prints summary when the window is over, if errors are suppressed.
+	timer *time.Timer
+}
+
+var suppressions = map[string]*suppression{}
+
+var suppressMutex sync.Mutex
+
+// FlushSuppressed prints summaries of errors suppressed so far
+// and starts over, so next errors are printed in full.
+// It's useful on shutdown to not lose counts of suppressed errors.
+func FlushSuppressed() {
+	suppressMutex.Lock()
+	defer suppressMutex.Unlock()
+	var rows []string
+	for fingerprint, s := range suppressions {
+		if s.count > 0 {
+			rows = append(rows, s.summary())
+		}
+		s.stop()
+		delete(suppressions, fingerprint)
+	}
+	if len(rows) > 0 {
+		fmt.Println(strings.Join(rows, "\n"))
+	}
+}
+
+// flushExpired prints summaries of errors, which window is over.
+// It's called by timers of suppressions.
+func flushExpired() {
+	suppressMutex.Lock()
+	defer suppressMutex.Unlock()
+	if rows := expiredSummaries(time.Now()); len(rows) > 0 {
+		fmt.Println(strings.Join(rows, "\n"))
+	}
+}
+
+// expiredSummaries removes errors, which window is over,
+// and returns summaries of suppressed ones.
+func expiredSummaries(now time.Time) []string {
+	var rows []string
+	for fingerprint, s := range suppressions {
+		if now.Sub(s.printed) < s.window {
+			continue
+		}
+		if s.count > 0 {
+			rows = append(rows, s.summary())
+		}
+		s.stop()
+		delete(suppressions, fingerprint)
+	}
+	return rows
+}
+
+// printSuppressed prints output of err, unless it's suppressed.
+func printSuppressed(err error, colorized bool, sprint func(err error) string) {
+	window := SuppressWindow
+	if window <= 0 || err == nil {
+		fmt.Println(sprint(err))
+		return
+	}
+	fingerprint := Fingerprint(err)
+	now := time.Now()
+
+	suppressMutex.Lock()
+	defer suppressMutex.Unlock()
+	rows := expiredSummaries(now)
+	if s, ok := suppressions[fingerprint]; ok {
+		s.count++
+		s.last = now
+		if s.timer == nil {
+			s.timer = time.AfterFunc(s.printed.Add(s.window).Sub(now), flushExpired)
+		}
+		message := fmt.Sprintf("same error as above (x%d in last %v)", s.count+1, elapsed(s.printed, now))
+		if colorized {
+			message = black(message)
+		}
+		rows = append(rows, message)
+	} else {
+		if fingerprint != "" {
+			suppressions[fingerprint] = &suppression{
+				message: strings.SplitN(err.Error(), "\n", 2)[0],
+				code:    fingerprintCode(fingerprint),
+				printed: now,
+				window:  window,
+			}
+		}
+		rows = append(rows, sprint(err))
+	}
+	fmt.Println(strings.Join(rows, "\n"))
+}
+
+func (s *suppression) stop() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
+
+func (s *suppression) summary() string {
+	return fmt.Sprintf(
+		"[%s] %s: suppressed %d %s in %v",
+		s.code, s.message, s.count, plural(s.count, "time", "times"), elapsed(s.printed, s.last),
+	)
+}
+
+// elapsed returns time passed from start to end, rounded up to seconds.
+func elapsed(start, end time.Time) time.Duration {
+	d := end.Sub(start)
+	if rounded := d.Truncate(time.Second); rounded < d || rounded == 0 {
+		return rounded + time.Second
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package tracerr_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestSuppressWindow(t *testing.T) {
+	tracerr.SuppressWindow = time.Hour
+	defer func() {
+		tracerr.FlushSuppressed()
+		tracerr.SuppressWindow = 0
+	}()
+
+	var outputs []string
+	var code string
+	for i := 0; i < 3; i++ {
+		err := tracerr.New("retry error")
+		code = tracerr.FingerprintCode(err)
+		outputs = append(outputs, captureOutput(func() {
+			tracerr.Print(err)
+		}))
+		outputs = append(outputs, captureOutput(func() {
+			tracerr.Print(errors.New("regular error"))
+		}))
+	}
+	outputs = append(outputs, captureOutput(tracerr.FlushSuppressed))
+
+	if !strings.HasPrefix(outputs[0], "retry error\n") || strings.Count(outputs[0], "\n") < 2 {
+		t.Errorf("outputs[0] = %#v; want full output", outputs[0])
+	}
+	expected := []string{
+		"same error as above (x2 in last 1s)\n",
+		"same error as above (x3 in last 1s)\n",
+		"[" + code + "] retry error: suppressed 2 times in 1s\n",
+	}
+	for i, output := range []string{outputs[2], outputs[4], outputs[6]} {
+		if output != expected[i] {
+			t.Errorf("output = %#v; want %#v", output, expected[i])
+		}
+	}
+	for _, i := range []int{1, 3, 5} {
+		if outputs[i] != "regular error\n" {
+			t.Errorf("outputs[%#v] = %#v; want %#v", i, outputs[i], "regular error\n")
+		}
+	}
+}
+
+func TestSuppressWindowExpired(t *testing.T) {
+	tracerr.SuppressWindow = 50 * time.Millisecond
+	defer func() {
+		tracerr.SuppressWindow = 0
+	}()
+	// Output is swapped for the whole test,
+	// since summary is printed by a timer in another goroutine.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		// Waits for the timer, if it's still running.
+		tracerr.FlushSuppressed()
+		os.Stdout = stdout
+		w.Close()
+	}()
+
+	var code string
+	for i := 0; i < 2; i++ {
+		err := tracerr.New("retry error")
+		code = tracerr.FingerprintCode(err)
+		tracerr.PrintSourceColor(err)
+	}
+	summary := "[" + code + "] retry error: suppressed 1 time in 1s\n"
+	// Nothing is printed after the window, but summary is printed anyway.
+	var output []byte
+	buf := make([]byte, 4096)
+	r.SetReadDeadline(time.Now().Add(time.Second))
+	for !bytes.HasSuffix(output, []byte(summary)) {
+		n, err := r.Read(buf)
+		output = append(output, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	expected := black("same error as above (x2 in last 1s)") + "\n" + summary
+	if !strings.HasPrefix(string(output), "retry error\n") || !strings.HasSuffix(string(output), expected) {
+		t.Errorf("output = %#v; want full output, repeat and summary", string(output))
+	}
+}
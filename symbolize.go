@@ -0,0 +1,288 @@
+package tracerr
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// encodingPrefix starts every string returned by EncodeCallers,
+// its number is a version of encoding.
+const encodingPrefix = "tracerr1"
+
+// anchorName is a name of the function,
+// which is used as a reference point for program counters.
+const anchorName = "github.com/ztrue/tracerr.EncodeCallers"
+
+var executable struct {
+	once    sync.Once
+	buildID string
+	err     error
+}
+
+// EncodeCallers returns a compact representation of error's stack trace,
+// which contains build ID of the executable and raw program counters.
+// It's much smaller than resolved frames and much cheaper to get,
+// so it could be logged instead of the stack trace
+// and resolved later with Symbolize, when it's actually needed.
+//
+// Program counters are stored relative to a function of this package,
+// so they don't depend on the address the executable is loaded at.
+// A number of frames dropped from the middle of deep stacks is stored as well.
+func EncodeCallers(err error) (string, error) {
+	e, ok := err.(Error)
+	if !ok {
+		return "", errors.New("tracerr: error has no stack trace")
+	}
+	pcs := e.Callers()
+	if len(pcs) == 0 {
+		return "", errors.New("tracerr: error has no program counters")
+	}
+	head, dropped := len(pcs), 0
+	if d, ok := e.(*errorData); ok {
+		head, dropped = d.stack().head, d.stack().dropped
+	}
+	executable.once.Do(func() {
+		path, err := os.Executable()
+		if err != nil {
+			executable.err = err
+			return
+		}
+		executable.buildID, executable.err = readBuildID(path)
+	})
+	if executable.err != nil {
+		return "", executable.err
+	}
+	anchor := reflect.ValueOf(EncodeCallers).Pointer()
+	buf := make([]byte, 0, binary.MaxVarintLen64*(len(pcs)+3))
+	buf = binary.AppendUvarint(buf, uint64(len(pcs)))
+	buf = binary.AppendUvarint(buf, uint64(head))
+	buf = binary.AppendUvarint(buf, uint64(dropped))
+	prev := anchor
+	for _, pc := range pcs {
+		buf = binary.AppendVarint(buf, int64(pc-prev))
+		prev = pc
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(buf)
+	return encodingPrefix + ":" + executable.buildID + ":" + encoded, nil
+}
+
+// Symbolize resolves stack trace encoded by EncodeCallers,
+// using the executable that produced it.
+// Build ID of the executable must match the encoded one.
+//
+// Resulting frames have the same paths and lines as StackTrace returns.
+// Functions are resolved with debug/gosym, which doesn't read inlining data,
+// so a frame of inlined call has a function it's inlined to,
+// rather than the inlined function itself.
+// Only ELF and Mach-O executables are supported.
+func Symbolize(binaryPath, encoded string) ([]Frame, error) {
+	frames, _, _, err := symbolize(binaryPath, encoded)
+	return frames, err
+}
+
+// SymbolizeError resolves stack trace encoded by EncodeCallers
+// the same way as Symbolize, and returns err with this stack trace,
+// so it could be printed.
+// Unlike frames returned by Symbolize, it also contains
+// a number of frames dropped from the middle of deep stacks, see DroppedFrames.
+func SymbolizeError(binaryPath, encoded string, err error) (Error, error) {
+	frames, gap, dropped, symbolizeErr := symbolize(binaryPath, encoded)
+	if symbolizeErr != nil {
+		return nil, symbolizeErr
+	}
+	e := CustomError(err, frames).(*errorData)
+	if dropped > 0 {
+		e.dropped = dropped
+		e.gap = gap
+	}
+	return e, nil
+}
+
+// symbolize resolves encoded stack trace and returns its frames,
+// a number of dropped frames and an index of the frame that follows them.
+func symbolize(binaryPath, encoded string) (frames []Frame, gap, dropped int, err error) {
+	parts := strings.Split(strings.TrimSpace(encoded), ":")
+	invalid := errors.New("tracerr: invalid encoded callers")
+	if len(parts) != 3 || parts[0] != encodingPrefix {
+		return nil, 0, 0, invalid
+	}
+	buildID, data := parts[1], parts[2]
+	buf, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("tracerr: invalid encoded callers: %v", err)
+	}
+	r := bytes.NewReader(buf)
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(len(buf)) {
+		return nil, 0, 0, invalid
+	}
+	head, err := binary.ReadUvarint(r)
+	if err != nil || head > n {
+		return nil, 0, 0, invalid
+	}
+	droppedPCs, err := binary.ReadUvarint(r)
+	if err != nil || droppedPCs > math.MaxInt32 {
+		return nil, 0, 0, invalid
+	}
+	deltas := make([]int64, n)
+	for i := range deltas {
+		deltas[i], err = binary.ReadVarint(r)
+		if err != nil {
+			return nil, 0, 0, invalid
+		}
+	}
+
+	actualID, err := readBuildID(binaryPath)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if actualID != buildID {
+		return nil, 0, 0, fmt.Errorf(
+			"tracerr: build ID mismatch, got %s, want %s",
+			actualID, buildID,
+		)
+	}
+	table, err := readSymbols(binaryPath)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	anchor := table.LookupFunc(anchorName)
+	if anchor == nil {
+		return nil, 0, 0, fmt.Errorf("tracerr: function %s not found in %s", anchorName, binaryPath)
+	}
+
+	frames = make([]Frame, 0, len(deltas))
+	pc := anchor.Entry
+	for i, delta := range deltas {
+		if uint64(i) == head {
+			gap = len(frames)
+		}
+		pc += uint64(delta)
+		// The same as in runtime.CallersFrames,
+		// program counters point to the instruction after the call.
+		path, line, fn := table.PCToLine(pc - 1)
+		if fn == nil {
+			continue
+		}
+		frames = append(frames, Frame{
+			Func: fn.Name,
+			Line: line,
+			Path: path,
+		})
+	}
+	return frames, gap, int(droppedPCs), nil
+}
+
+// readBuildID returns Go build ID of the executable.
+func readBuildID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if ef, err := elf.NewFile(f); err == nil {
+		if s := ef.Section(".note.go.buildid"); s != nil {
+			data, err := s.Data()
+			if err != nil {
+				return "", err
+			}
+			return parseBuildIDNote(data, ef.ByteOrder)
+		}
+	}
+	// Other formats store build ID as a plain string at the beginning of text.
+	head := make([]byte, 32*1024)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+	prefix := []byte("\xff Go build ID: \"")
+	i := bytes.Index(head, prefix)
+	if i < 0 {
+		return "", fmt.Errorf("tracerr: build ID not found in %s", path)
+	}
+	head = head[i+len(prefix):]
+	j := bytes.IndexByte(head, '"')
+	if j < 0 {
+		return "", fmt.Errorf("tracerr: build ID not found in %s", path)
+	}
+	return string(head[:j]), nil
+}
+
+// parseBuildIDNote parses ELF note, which contains Go build ID.
+func parseBuildIDNote(data []byte, order binary.ByteOrder) (string, error) {
+	const (
+		headerSize = 12
+		noteName   = "Go\x00\x00"
+	)
+	if len(data) < headerSize+len(noteName) {
+		return "", errors.New("tracerr: invalid build ID note")
+	}
+	nameSize := order.Uint32(data)
+	descSize := order.Uint32(data[4:])
+	if nameSize != uint32(len(noteName)) || string(data[headerSize:headerSize+len(noteName)]) != noteName {
+		return "", errors.New("tracerr: invalid build ID note")
+	}
+	desc := data[headerSize+len(noteName):]
+	if uint32(len(desc)) < descSize {
+		return "", errors.New("tracerr: invalid build ID note")
+	}
+	return string(desc[:descSize]), nil
+}
+
+// readSymbols reads Go symbol and line tables of the executable.
+func readSymbols(path string) (*gosym.Table, error) {
+	var (
+		symtab, pclntab []byte
+		text            uint64
+	)
+	if ef, err := elf.Open(path); err == nil {
+		defer ef.Close()
+		if s := ef.Section(".gosymtab"); s != nil {
+			if symtab, err = s.Data(); err != nil {
+				return nil, err
+			}
+		}
+		if s := ef.Section(".gopclntab"); s != nil {
+			if pclntab, err = s.Data(); err != nil {
+				return nil, err
+			}
+		}
+		if s := ef.Section(".text"); s != nil {
+			text = s.Addr
+		}
+	} else if mf, err := macho.Open(path); err == nil {
+		defer mf.Close()
+		if s := mf.Section("__gosymtab"); s != nil {
+			if symtab, err = s.Data(); err != nil {
+				return nil, err
+			}
+		}
+		if s := mf.Section("__gopclntab"); s != nil {
+			if pclntab, err = s.Data(); err != nil {
+				return nil, err
+			}
+		}
+		if s := mf.Section("__text"); s != nil {
+			text = s.Addr
+		}
+	} else {
+		return nil, fmt.Errorf("tracerr: unsupported executable format of %s", path)
+	}
+	if pclntab == nil {
+		return nil, fmt.Errorf("tracerr: line table not found in %s", path)
+	}
+	return gosym.NewTable(symtab, gosym.NewLineTable(pclntab, text))
+}
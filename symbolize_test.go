@@ -0,0 +1,132 @@
+package tracerr_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestSymbolize(t *testing.T) {
+	err := addFrameA("error to symbolize").(tracerr.Error)
+	encoded, encodeErr := tracerr.EncodeCallers(err)
+	if encodeErr != nil {
+		t.Fatalf("tracerr.EncodeCallers(err) error = %#v", encodeErr)
+	}
+	if !strings.HasPrefix(encoded, "tracerr1:") {
+		t.Errorf("tracerr.EncodeCallers(err) = %#v; want tracerr1: prefix", encoded)
+	}
+	path, pathErr := os.Executable()
+	if pathErr != nil {
+		t.Fatal(pathErr)
+	}
+	frames, symbolizeErr := tracerr.Symbolize(path, encoded)
+	if symbolizeErr != nil {
+		t.Fatalf("tracerr.Symbolize(path, encoded) error = %#v", symbolizeErr)
+	}
+	expected := err.StackTrace()
+	if len(frames) != len(expected) {
+		t.Fatalf("len(frames) = %#v; want %#v", len(frames), len(expected))
+	}
+	// Function of an inlined call is the function it's inlined to,
+	// which is the next frame that isn't inlined.
+	var resolved []runtime.Frame
+	cf := runtime.CallersFrames(err.Callers())
+	for {
+		f, more := cf.Next()
+		resolved = append(resolved, f)
+		if !more {
+			break
+		}
+	}
+	for i := range expected {
+		if frames[i].Path != expected[i].Path || frames[i].Line != expected[i].Line {
+			t.Errorf("frames[%#v] = %#v; want %#v", i, frames[i], expected[i])
+		}
+		function := expected[i].Func
+		for j := i; j < len(resolved) && resolved[j].Func == nil; j++ {
+			function = expected[j+1].Func
+		}
+		if frames[i].Func != function {
+			t.Errorf("frames[%#v].Func = %#v; want %#v", i, frames[i].Func, function)
+		}
+	}
+}
+
+func TestSymbolizeError(t *testing.T) {
+	defer func(maxFrames, tailFrames int) {
+		tracerr.MaxFrames = maxFrames
+		tracerr.TailFrames = tailFrames
+	}(tracerr.MaxFrames, tracerr.TailFrames)
+	tracerr.MaxFrames = 5
+	tracerr.TailFrames = 2
+	err := addFrameA("error to symbolize").(tracerr.Error)
+	encoded, encodeErr := tracerr.EncodeCallers(err)
+	if encodeErr != nil {
+		t.Fatalf("tracerr.EncodeCallers(err) error = %#v", encodeErr)
+	}
+	path, pathErr := os.Executable()
+	if pathErr != nil {
+		t.Fatal(pathErr)
+	}
+	symbolized, symbolizeErr := tracerr.SymbolizeError(path, encoded, err.Unwrap())
+	if symbolizeErr != nil {
+		t.Fatalf("tracerr.SymbolizeError(path, encoded, err) error = %#v", symbolizeErr)
+	}
+	if tracerr.DroppedFrames(symbolized) != tracerr.DroppedFrames(err) || tracerr.DroppedFrames(err) == 0 {
+		t.Errorf(
+			"tracerr.DroppedFrames(symbolized) = %#v; want %#v",
+			tracerr.DroppedFrames(symbolized), tracerr.DroppedFrames(err),
+		)
+	}
+	// Head frames could differ by inlined calls, but the tail has none.
+	frames, expected := symbolized.StackTrace(), err.StackTrace()
+	tail := frames[len(frames)-2:]
+	if !reflect.DeepEqual(tail, expected[len(expected)-2:]) {
+		t.Errorf("tail of symbolized.StackTrace() = %#v; want %#v", tail, expected[len(expected)-2:])
+	}
+	omitted := fmt.Sprintf("\n[... %d frames omitted ...]\n", tracerr.DroppedFrames(err))
+	if !strings.Contains(tracerr.Sprint(symbolized), omitted) {
+		t.Errorf("tracerr.Sprint(symbolized) = %#v; want %#v", tracerr.Sprint(symbolized), omitted)
+	}
+}
+
+func TestSymbolizeErrors(t *testing.T) {
+	if _, err := tracerr.EncodeCallers(errors.New("regular error")); err == nil {
+		t.Errorf("tracerr.EncodeCallers(regular error) error = nil; want error")
+	}
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := []string{
+		"",
+		"tracerr1:id",
+		"tracerr0:id:AQI",
+		"tracerr1:id:!!!",
+		"tracerr1:id:Cg",
+	}
+	for _, encoded := range invalid {
+		if _, err := tracerr.Symbolize(path, encoded); err == nil {
+			t.Errorf("tracerr.Symbolize(path, %#v) error = nil; want error", encoded)
+		}
+	}
+	encoded, err := tracerr.EncodeCallers(tracerr.New("some error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(encoded, ":")
+	parts[1] = "other"
+	_, err = tracerr.Symbolize(path, strings.Join(parts, ":"))
+	if err == nil || !strings.Contains(err.Error(), "build ID mismatch") {
+		t.Errorf("tracerr.Symbolize() error = %#v; want build ID mismatch", err)
+	}
+	if _, err := tracerr.Symbolize("error_test.go", encoded); err == nil {
+		t.Errorf("tracerr.Symbolize(error_test.go) error = nil; want error")
+	}
+}
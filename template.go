@@ -0,0 +1,197 @@
+package tracerr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Built-in templates for SprintTemplate.
+const (
+	// CompactTemplate outputs a frame per line, without source.
+	CompactTemplate = `{{.Message}}
+{{- range .Frames}}
+{{if .Note}}    {{.Note}}{{else}}    at {{prettyFunc .Func}} ({{trimPath .Path}}:{{.Line}}){{end}}
+{{- end}}`
+	// JavaTemplate outputs frames in the style of Java exceptions.
+	JavaTemplate = `{{.Message}}
+{{- range .Frames}}
+{{if .Note}}	... {{.Note}}{{else}}	at {{.Func}}({{base .Path}}:{{.Line}}){{end}}
+{{- end}}
+{{- range .Chain}}
+Caused by: {{.}}
+{{- end}}`
+	// PythonTemplate outputs frames in the style of Python tracebacks,
+	// the most recent call last, with traced lines.
+	PythonTemplate = `Traceback (most recent call last):
+{{- range reverse .Frames}}
+{{- if .Note}}
+  {{.Note}}
+{{- else}}
+  File "{{.Path}}", line {{.Line}}, in {{prettyFunc .Func}}
+{{- if ge .Highlight 0}}
+    {{trim (index .Source .Highlight).Text}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{.Message}}`
+)
+
+// TemplateFuncs returns helper functions available in templates:
+//
+//	color "red" text    wraps text in ANSI color: bold, black, red or yellow
+//	trimPath path       makes path relative to working directory, GOROOT or module cache
+//	prettyFunc name     removes import path of package from function name
+//	sourceLines frame   returns source lines of frame, formatted as in SprintSource
+//	pad width text      pads text with spaces to width, to the left if width is negative
+//	code fingerprint    returns a short code of fingerprint, see FingerprintCode
+//	reverse frames      returns frames in reverse order
+//	base path           returns the last element of path
+//	trim text           removes leading and trailing white space
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"color":       templateColor,
+		"trimPath":    trimPath,
+		"prettyFunc":  prettyFunc,
+		"sourceLines": sourceLines,
+		"pad":         pad,
+		"code":        fingerprintCode,
+		"reverse":     reverse,
+		"base":        filepath.Base,
+		"trim":        strings.TrimSpace,
+	}
+}
+
+// ParseTemplate parses a template with helper functions, see TemplateFuncs.
+// Template is executed with *Report.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("tracerr").Funcs(TemplateFuncs()).Parse(text)
+}
+
+// RenderTemplate returns report rendered with a template.
+func RenderTemplate(t *template.Template, r *Report) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// maxTemplates is a maximum number of templates cached by SprintTemplate.
+const maxTemplates = 16
+
+var (
+	templates      = map[string]*template.Template{}
+	templatesMutex sync.Mutex
+)
+
+// SprintTemplate returns error output rendered with a template,
+// such as CompactTemplate, JavaTemplate or PythonTemplate.
+// Number of source lines is defined by the same rules as in PrintSource.
+// See also TemplateFuncs and Report.
+//
+// Only a few recently used templates are kept parsed,
+// use ParseTemplate and RenderTemplate to render many different templates.
+func SprintTemplate(err error, text string, nums ...int) (string, error) {
+	templatesMutex.Lock()
+	t, ok := templates[text]
+	if !ok {
+		var parseErr error
+		t, parseErr = ParseTemplate(text)
+		if parseErr != nil {
+			templatesMutex.Unlock()
+			return "", parseErr
+		}
+		if len(templates) >= maxTemplates {
+			templates = map[string]*template.Template{}
+		}
+		templates[text] = t
+	}
+	templatesMutex.Unlock()
+	return RenderTemplate(t, BuildReport(err, NewReportOptions(nums...)))
+}
+
+func templateColor(name, text string) (string, error) {
+	switch name {
+	case "bold":
+		return bold(text), nil
+	case "black":
+		return black(text), nil
+	case "red":
+		return red(text), nil
+	case "yellow":
+		return yellow(text), nil
+	}
+	return "", fmt.Errorf("tracerr: unknown color %s", name)
+}
+
+// trimPath makes path relative to working directory, GOROOT or module cache.
+func trimPath(path string) string {
+	slashed := filepath.ToSlash(path)
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	if root := goroot(); root != "" && strings.HasPrefix(slashed, root+"/src/") {
+		return slashed[len(root)+len("/src/"):]
+	}
+	if i := strings.Index(slashed, "/pkg/mod/"); i >= 0 {
+		return slashed[i+len("/pkg/mod/"):]
+	}
+	return path
+}
+
+// prettyFunc removes import path of package from function name,
+// such as "pkg.(*T).Method" for "github.com/user/repo/pkg.(*T).Method".
+func prettyFunc(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// sourceLines returns formatted source lines of frame, without warnings.
+func sourceLines(f ReportFrame) []string {
+	f.Warning = ""
+	return sourceRows(nil, f, false)
+}
+
+func pad(width int, text string) string {
+	if width < 0 {
+		return fmt.Sprintf("%*s", -width, text)
+	}
+	return fmt.Sprintf("%-*s", width, text)
+}
+
+func reverse(frames []ReportFrame) []ReportFrame {
+	reversed := make([]ReportFrame, len(frames))
+	for i, frame := range frames {
+		reversed[len(frames)-1-i] = frame
+	}
+	return reversed
+}
+
+var (
+	gorootPath string
+	gorootOnce sync.Once
+)
+
+// goroot returns GOROOT the binary is built with, taken from path of a runtime frame.
+// It's empty if paths are trimmed.
+func goroot() string {
+	gorootOnce.Do(func() {
+		var pcs [1]uintptr
+		runtime.Callers(0, pcs[:])
+		frame, _ := runtime.CallersFrames(pcs[:]).Next()
+		path := filepath.ToSlash(frame.File)
+		if i := strings.LastIndex(path, "/src/runtime/"); i > 0 {
+			gorootPath = path[:i]
+		}
+	})
+	return gorootPath
+}
@@ -0,0 +1,123 @@
+package tracerr_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+func TestSprintTemplate(t *testing.T) {
+	wd, _ := os.Getwd()
+	pc := reflect.ValueOf(fmt.Println).Pointer()
+	printPath, _ := runtime.FuncForPC(pc).FileLine(pc)
+	frames := []tracerr.Frame{
+		{Func: "github.com/ztrue/tracerr_test.addFrameA", Line: 9, Path: filepath.Join(wd, "error_helper_test.go")},
+		{Func: "fmt.Println", Line: 314, Path: printPath},
+		{Func: "github.com/user/lib.(*Client).Do.func1", Line: 42, Path: "/home/john/go/pkg/mod/github.com/user/lib@v1.0.0/client.go"},
+	}
+	err := tracerr.CustomError(fmt.Errorf("request failed: %w", errors.New("timeout")), frames)
+
+	cases := []struct {
+		Template string
+		Nums     []int
+		Expected string
+	}{
+		{
+			Template: tracerr.CompactTemplate,
+			Nums:     []int{0},
+			Expected: strings.Join([]string{
+				"request failed: timeout",
+				"    at tracerr_test.addFrameA (error_helper_test.go:9)",
+				"    at fmt.Println (fmt/print.go:314)",
+				"    at lib.(*Client).Do.func1 (github.com/user/lib@v1.0.0/client.go:42)",
+			}, "\n"),
+		},
+		{
+			Template: tracerr.JavaTemplate,
+			Nums:     []int{0},
+			Expected: strings.Join([]string{
+				"request failed: timeout",
+				"\tat github.com/ztrue/tracerr_test.addFrameA(error_helper_test.go:9)",
+				"\tat fmt.Println(print.go:314)",
+				"\tat github.com/user/lib.(*Client).Do.func1(client.go:42)",
+				"Caused by: timeout",
+			}, "\n"),
+		},
+		{
+			Template: tracerr.PythonTemplate,
+			Nums:     []int{1},
+			Expected: strings.Join([]string{
+				"Traceback (most recent call last):",
+				`  File "/home/john/go/pkg/mod/github.com/user/lib@v1.0.0/client.go", line 42, in lib.(*Client).Do.func1`,
+				`  File "` + frames[1].Path + `", line 314, in fmt.Println`,
+				"    " + strings.TrimSpace(sourceLine(t, frames[1].Path, 314)),
+				`  File "` + frames[0].Path + `", line 9, in tracerr_test.addFrameA`,
+				"    return addFrameB(message)",
+				"request failed: timeout",
+			}, "\n"),
+		},
+		{
+			Template: `{{range .Frames}}{{pad 12 (base .Path) | color "bold"}}|{{pad -4 (print .Line)}}` +
+				`{{range sourceLines .}}` + "\n" + `{{.}}{{end}}` + "\n" + `{{end}}`,
+			Nums: []int{2, 0},
+			Expected: bold("print.go    ") + "| 314\n" +
+				strings.Join(sourceRowsAt(t, frames[1].Path, 312, 314), "\n") + "\n" +
+				bold("client.go   ") + "|  42\n",
+		},
+	}
+	for i, c := range cases {
+		if i == 3 {
+			err = tracerr.CustomError(errors.New("other"), frames[1:])
+		}
+		output, renderErr := tracerr.SprintTemplate(err, c.Template, c.Nums...)
+		if renderErr != nil {
+			t.Errorf("tracerr.SprintTemplate(err, cases[%#v].Template) error = %#v", i, renderErr)
+			continue
+		}
+		if output != c.Expected {
+			t.Errorf("tracerr.SprintTemplate(err, cases[%#v].Template) = %#v; want %#v", i, output, c.Expected)
+		}
+	}
+
+	if _, renderErr := tracerr.SprintTemplate(err, "{{.Message"); renderErr == nil {
+		t.Errorf("tracerr.SprintTemplate(err, invalid) error = nil; want error")
+	}
+	if _, renderErr := tracerr.SprintTemplate(err, `{{color "pink" .Message}}`); renderErr == nil {
+		t.Errorf("tracerr.SprintTemplate(err, unknown color) error = nil; want error")
+	}
+	if output, _ := tracerr.SprintTemplate(nil, tracerr.CompactTemplate); output != "" {
+		t.Errorf("tracerr.SprintTemplate(nil, CompactTemplate) = %#v; want %#v", output, "")
+	}
+	// Cache of parsed templates is bounded, templates still work after it's reset.
+	for i := 0; i < 100; i++ {
+		text := fmt.Sprintf("%d {{.Message}}", i)
+		if output, _ := tracerr.SprintTemplate(err, text); output != fmt.Sprintf("%d other", i) {
+			t.Fatalf("tracerr.SprintTemplate(err, %#v) = %#v; want %#v", text, output, fmt.Sprintf("%d other", i))
+		}
+	}
+}
+
+func sourceLine(t *testing.T, path string, line int) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(string(b), "\n")[line-1]
+}
+
+func sourceRowsAt(t *testing.T, path string, from, to int) []string {
+	t.Helper()
+	var rows []string
+	for i := from; i <= to; i++ {
+		rows = append(rows, fmt.Sprintf("%d\t%s", i, sourceLine(t, path, i)))
+	}
+	return rows
+}
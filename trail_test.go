@@ -0,0 +1,129 @@
+package tracerr_test
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ztrue/tracerr"
+)
+
+// enableTrail sets MaxTrail for a test and returns a function that restores it.
+func enableTrail() func() {
+	max := tracerr.MaxTrail
+	tracerr.MaxTrail = 32
+	return func() {
+		tracerr.MaxTrail = max
+	}
+}
+
+func TestTrail(t *testing.T) {
+	defer enableTrail()()
+	origin := tracerr.New("some error")
+	_, path, line, _ := runtime.Caller(0)
+	err := tracerr.Wrap(origin)
+	err = tracerr.WrapNote(err, "while loading %s", "config")
+
+	expected := []tracerr.TrailPoint{
+		{Frame: tracerr.Frame{Func: "github.com/ztrue/tracerr_test.TestTrail", Line: line + 1, Path: path}},
+		{Frame: tracerr.Frame{Func: "github.com/ztrue/tracerr_test.TestTrail", Line: line + 2, Path: path}, Note: "while loading config"},
+	}
+	if trail := tracerr.Trail(err); !reflect.DeepEqual(trail, expected) {
+		t.Errorf("tracerr.Trail(err) = %#v; want %#v", trail, expected)
+	}
+	if trail := tracerr.Trail(origin); trail != nil {
+		t.Errorf("tracerr.Trail(origin) = %#v; want nil", trail)
+	}
+	if err.Error() != "some error" {
+		t.Errorf("err.Error() = %#v; want %#v", err.Error(), "some error")
+	}
+	if !reflect.DeepEqual(err.StackTrace(), origin.StackTrace()) {
+		t.Errorf("err.StackTrace() = %#v; want %#v", err.StackTrace(), origin.StackTrace())
+	}
+	if !errors.Is(err, origin) {
+		t.Error("errors.Is(err, origin) = false; want true")
+	}
+	if errors.Is(err, tracerr.New("some error")) {
+		t.Error("errors.Is(err, other) = true; want false")
+	}
+	if trail := tracerr.Trail(origin); trail != nil {
+		t.Errorf("origin is modified by Wrap: %#v", trail)
+	}
+
+	output := tracerr.Sprint(err)
+	expectedTail := "returned through:\n" +
+		expected[0].Frame.String() + "\n" +
+		expected[1].Frame.String() + "\n" +
+		"\twhile loading config"
+	if !strings.HasSuffix(output, expectedTail) {
+		t.Errorf("tracerr.Sprint(err) = %#v; want suffix %#v", output, expectedTail)
+	}
+	if !strings.HasPrefix(output, tracerr.Sprint(origin)+"\n") {
+		t.Errorf("tracerr.Sprint(err) = %#v; want prefix %#v", output, tracerr.Sprint(origin))
+	}
+
+	parsed, parseErr := tracerr.Parse(tracerr.SprintSourceColor(err))
+	if parseErr != nil {
+		t.Fatalf("tracerr.Parse() error = %v", parseErr)
+	}
+	if !reflect.DeepEqual(tracerr.Trail(parsed), expected) {
+		t.Errorf("tracerr.Trail(parsed) = %#v; want %#v", tracerr.Trail(parsed), expected)
+	}
+	if !reflect.DeepEqual(parsed.StackTrace(), err.StackTrace()) {
+		t.Errorf("parsed.StackTrace() = %#v; want %#v", parsed.StackTrace(), err.StackTrace())
+	}
+
+	data, jsonErr := tracerr.MarshalJSON(err)
+	if jsonErr != nil {
+		t.Fatalf("tracerr.MarshalJSON() error = %v", jsonErr)
+	}
+	decoded, jsonErr := tracerr.UnmarshalJSON(data)
+	if jsonErr != nil {
+		t.Fatalf("tracerr.UnmarshalJSON() error = %v", jsonErr)
+	}
+	if !reflect.DeepEqual(tracerr.Trail(decoded), expected) {
+		t.Errorf("tracerr.Trail(decoded) = %#v; want %#v", tracerr.Trail(decoded), expected)
+	}
+}
+
+func TestTrailNewError(t *testing.T) {
+	defer enableTrail()()
+	_, path, line, _ := runtime.Caller(0)
+	err := tracerr.WrapNote(errors.New("some error"), "note")
+	expected := []tracerr.TrailPoint{
+		{Frame: tracerr.Frame{Func: "github.com/ztrue/tracerr_test.TestTrailNewError", Line: line + 1, Path: path}, Note: "note"},
+	}
+	if trail := tracerr.Trail(err); !reflect.DeepEqual(trail, expected) {
+		t.Errorf("tracerr.Trail(err) = %#v; want %#v", trail, expected)
+	}
+	if tracerr.WrapNote(nil, "note") != nil {
+		t.Error("tracerr.WrapNote(nil) != nil")
+	}
+	if trail := tracerr.Trail(errors.New("regular error")); trail != nil {
+		t.Errorf("tracerr.Trail(regular error) = %#v; want nil", trail)
+	}
+}
+
+func TestMaxTrail(t *testing.T) {
+	defer enableTrail()()
+
+	err := tracerr.New("some error")
+	tracerr.MaxTrail = 0
+	if wrapped := tracerr.Wrap(err); wrapped != err {
+		t.Errorf("tracerr.Wrap(err) = %#v; want the same error with MaxTrail = 0", wrapped)
+	}
+	if wrapped := tracerr.WrapNote(err, "note"); wrapped != err {
+		t.Errorf("tracerr.WrapNote(err) = %#v; want the same error with MaxTrail = 0", wrapped)
+	}
+
+	tracerr.MaxTrail = 2
+	wrapped := err
+	for i := 0; i < 5; i++ {
+		wrapped = tracerr.Wrap(wrapped)
+	}
+	if len(tracerr.Trail(wrapped)) != 2 {
+		t.Errorf("len(tracerr.Trail(wrapped)) = %d; want 2", len(tracerr.Trail(wrapped)))
+	}
+}